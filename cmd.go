@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"go/ast"
@@ -11,7 +12,9 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -30,8 +33,61 @@ var (
 	runTestFlags flag.FlagSet
 	instrument   = runTestFlags.String("instrument", "", "extra packages to enable for debugging")
 	work         = runTestFlags.Bool("godebugwork", false, "print the name of the temporary work directory and do not delete it when exiting")
+	stopTheWorld = runTestFlags.Bool("stoptheworld", false, "pause every instrumented goroutine while the debugger is paused")
+	catchPanic   = runTestFlags.Bool("catchpanic", true, "pause when a panic passes through an instrumented function")
+	breakOnFail  = runTestFlags.Bool("break-on-fail", false, "pause where a test is about to fail (godebug test only)")
+	breakFuncs   funcList
 )
 
+func init() {
+	runTestFlags.Var(&breakFuncs, "break", "pause on entry to the named function (may be repeated)")
+}
+
+// These environment variables pass flags on to the debugged program.
+// They must match the names the runtime library checks.
+const (
+	stopTheWorldEnvVar = "GODEBUG_STOP_THE_WORLD"
+	catchPanicEnvVar   = "GODEBUG_CATCH_PANIC"
+	breakOnFailEnvVar  = "GODEBUG_BREAK_ON_FAIL"
+	breakEnvVar        = "GODEBUG_BREAK"
+	launchedEnvVar     = "GODEBUG_LAUNCHED"
+)
+
+// funcList holds the values of the -break flag.
+type funcList []string
+
+func (l *funcList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *funcList) Set(spec string) error {
+	name := spec
+	if i := strings.IndexAny(spec, " \t"); i >= 0 {
+		name = spec[:i]
+	}
+	switch {
+	case name == "":
+		return errors.New("expected a function name or a regular expression in single quotes")
+	case strings.HasPrefix(spec, "'"):
+		i := strings.Index(spec[1:], "'")
+		if i < 0 {
+			return fmt.Errorf("the regular expression %s is missing its closing quote", spec)
+		}
+		if _, err := regexp.Compile(spec[1 : i+1]); err != nil {
+			return err
+		}
+	case strings.Contains(name, ":"), isNumber(name), strings.HasSuffix(name, ".go"):
+		return fmt.Errorf("%s is a location, not a function. Set breakpoints at lines with _ = \"breakpoint\"", name)
+	}
+	*l = append(*l, spec)
+	return nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
 func init() {
 	// Hack for godebug's CI system. The CI can't override PATH in its builders,
 	// but it can set new environment variables.
@@ -61,7 +117,7 @@ Use "godebug help [command]" for more information about a command.
 
 func runUsage() {
 	log.Print(
-		`usage: godebug run [-godebugwork] [-instrument pkgs...] gofiles... [--] [arguments...]
+		`usage: godebug run [-godebugwork] [-instrument pkgs...] [-stoptheworld] [-catchpanic=false] [-break func...] gofiles... [--] [arguments...]
 
 Run is a wrapper around 'go run'. It generates debugging code for
 the named Go source files and runs 'go run' on the result.
@@ -77,12 +133,24 @@ must not be relative.
 
 If -godebugwork is set, godebug will print the name of the
 temporary work directory and not delete it when exiting.
+
+If -stoptheworld is set, goroutines other than the one being
+debugged wait at their next line while the debugger is paused.
+
+When a panic passes through an instrumented function, the debugger
+pauses at the last line that function ran, and the panic continues
+when the program resumes. Pass -catchpanic=false to turn this off.
+
+The -break flag sets a breakpoint on entry to every instrumented
+function with the given name, such as main.(*T).Method, or whose
+name matches a regular expression in single quotes. It may be
+given more than once.
 `)
 }
 
 func testUsage() {
 	log.Print(
-		`usage: godebug test [-godebugwork] [-instrument pkgs...] [packages] [flags for test binary]
+		`usage: godebug test [-godebugwork] [-instrument pkgs...] [-stoptheworld] [-catchpanic=false] [-break func...] [-break-on-fail] [packages] [flags for test binary]
 
 Test is a wrapper around 'go test'. It generates debugging code for
 the tests in the named packages and runs 'go test' on the result.
@@ -99,6 +167,22 @@ must not be relative.
 If -godebugwork is set, godebug will print the name of the
 temporary work directory and not delete it when exiting.
 
+If -stoptheworld is set, goroutines other than the one being
+debugged wait at their next line while the debugger is paused.
+
+When a panic passes through an instrumented function, the debugger
+pauses at the last line that function ran, and the panic continues
+when the program resumes. Pass -catchpanic=false to turn this off.
+
+The -break flag sets a breakpoint on entry to every instrumented
+function with the given name, such as main.(*T).Method, or whose
+name matches a regular expression in single quotes. It may be
+given more than once.
+
+If -break-on-fail is set, the debugger pauses at every call to
+Error, Errorf, Fatal, Fatalf or Fail on a *testing.T or *testing.B
+in the instrumented tests, before the call runs.
+
 See also: 'go help testflag'.
 `)
 }
@@ -176,6 +260,9 @@ func doHelp(args []string) {
 func doRun(args []string) {
 	// Parse arguments.
 	exitIfErr(runTestFlags.Parse(args))
+	if *breakOnFail {
+		logFatal("godebug run: -break-on-fail only applies to godebug test")
+	}
 
 	// Separate the .go files from the arguments to the binary we're building.
 	gofiles, rest := getGoFiles()
@@ -200,6 +287,8 @@ func doRun(args []string) {
 	// which 'go run' does not have.
 	bin := filepath.Join(tmpDir, "godebug.a.out")
 	shellGo(tmpDir, []string{"build", "-o", bin}, mapToTmpDir(tmpDir, gofiles))
+	setDebuggerEnv()
+	ignoreInterrupts()
 	shell("", bin, rest...)
 }
 
@@ -233,9 +322,44 @@ func doTest(args []string) {
 	bin := filepath.Join(tmpDir, "godebug-test-bin.test")
 	goArgs := []string{"test", "-c", "-o", bin}
 	shellGo(tmpDir, goArgs, mapPkgsToTmpDir(packages))
+	setDebuggerEnv()
+	ignoreInterrupts()
 	shell("", bin, testFlags...)
 }
 
+// setDebuggerEnv tells the debugged program that godebug is running it, and passes
+// the flags for the debugger on to it.
+func setDebuggerEnv() {
+	os.Setenv(launchedEnvVar, "true")
+	if *stopTheWorld {
+		os.Setenv(stopTheWorldEnvVar, "true")
+	}
+	if !*catchPanic {
+		os.Setenv(catchPanicEnvVar, "false")
+	}
+	if *breakOnFail {
+		os.Setenv(breakOnFailEnvVar, "true")
+	}
+	if len(breakFuncs) > 0 {
+		os.Setenv(breakEnvVar, strings.Join(breakFuncs, "\n"))
+	}
+}
+
+// ignoreInterrupts keeps godebug running when the user presses Ctrl-C while the
+// debugged program runs. The program receives the interrupt too, and either
+// pauses in the debugger or exits, after which godebug cleans up and exits.
+func ignoreInterrupts() {
+	// Ignore undoes the handler that atexit.TrapSignals installed. The signal is then
+	// caught rather than left ignored, since the program would inherit that.
+	signal.Ignore(os.Interrupt)
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		for range interrupts {
+		}
+	}()
+}
+
 func generateSourceFiles(conf *loader.Config, subcommand string) (tmpDirPath string) {
 	// Make a temp directory.
 	tmpDir := makeTmpDir()
@@ -488,7 +612,7 @@ func doOutput(args []string) {
 }
 
 func parseTestArguments(args []string) (packages, testFlags []string) {
-	// format: [-godebugwork] [-instrument pkgs...] [packages] [testFlags]
+	// format: [-godebugwork] [-instrument pkgs...] [-stoptheworld] [-catchpanic=false] [-break func...] [-break-on-fail] [packages] [testFlags]
 
 	// Find first unrecognized flag.
 	sep := len(args)
@@ -498,7 +622,10 @@ func parseTestArguments(args []string) (packages, testFlags []string) {
 		}
 		if strings.HasPrefix(arg, "-") &&
 			!strings.HasPrefix(arg, "-instrument") &&
-			!strings.HasPrefix(arg, "-godebugwork") {
+			!strings.HasPrefix(arg, "-godebugwork") &&
+			!strings.HasPrefix(arg, "-stoptheworld") &&
+			!strings.HasPrefix(arg, "-catchpanic") &&
+			!strings.HasPrefix(arg, "-break") {
 			sep = i
 			break
 		}
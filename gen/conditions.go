@@ -0,0 +1,122 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+
+	"github.com/mailgun/godebug/Godeps/_workspace/src/golang.org/x/tools/go/types"
+)
+
+// Breakpoints written in the source may have a condition, as in
+// _ = "breakpoint if x > 10", and assertions, as in _ = "assert: len(queue) < 100",
+// pause the program when their condition is false.
+const (
+	conditionalPrefix = "breakpoint if "
+	assertPrefix      = "assert:"
+)
+
+// sourceCondition returns the condition of node if it is a conditional breakpoint or an assertion.
+func sourceCondition(node ast.Node) (cond string, assert, ok bool) {
+	s, ok := blankString(node)
+	switch {
+	case !ok:
+	case strings.HasPrefix(s, conditionalPrefix):
+		return strings.TrimSpace(strings.TrimPrefix(s, conditionalPrefix)), false, true
+	case strings.HasPrefix(s, assertPrefix):
+		return strings.TrimSpace(strings.TrimPrefix(s, assertPrefix)), true, true
+	}
+	return "", false, false
+}
+
+// checkConditions parses and type-checks the conditions of the conditional
+// breakpoints and assertions in f, and returns the expression that guards each
+// one in the generated code. Since a mistake in a condition would otherwise be
+// reported as an error in the generated code, it is reported here, at the line
+// of the breakpoint, and generation stops.
+func checkConditions(f *ast.File) map[ast.Node]string {
+	result := make(map[ast.Node]string)
+	var stack []ast.Node
+	ast.Inspect(f, func(node ast.Node) bool {
+		if node == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, node)
+		cond, assert, ok := sourceCondition(node)
+		if !ok {
+			return true
+		}
+		kind := "breakpoint"
+		if assert {
+			kind = "assertion"
+		}
+		if err := checkCondition(cond, stack); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: invalid %s condition %q: %v\n", fs.Position(node.Pos()), kind, cond, err)
+			os.Exit(1)
+		}
+		if assert {
+			cond = "!(" + cond + ")"
+		}
+		result[node] = cond
+		return true
+	})
+	return result
+}
+
+// checkCondition reports whether cond is a boolean expression that can be
+// evaluated at the last node of path, which holds the nodes enclosing it.
+func checkCondition(cond string, path []ast.Node) error {
+	e, err := parser.ParseExpr(cond)
+	if err != nil {
+		return err
+	}
+	if scopes == nil {
+		// The compiler will check the generated code instead.
+		return nil
+	}
+	tv, err := types.EvalNode(token.NewFileSet(), e, pkg, scopeAt(path))
+	if err != nil {
+		if terr, ok := err.(types.Error); ok {
+			return errors.New(terr.Msg)
+		}
+		return err
+	}
+	if !tv.IsValue() {
+		return fmt.Errorf("%s is not a value", cond)
+	}
+	if b, ok := tv.Type.Underlying().(*types.Basic); !ok || b.Info()&types.IsBoolean == 0 {
+		return fmt.Errorf("%s has type %s, not bool", cond, tv.Type)
+	}
+	return nil
+}
+
+// scopeAt returns the scope at the last node of path. Unlike the scopes the
+// type checker recorded, it only holds the local names declared before that node.
+func scopeAt(path []ast.Node) *types.Scope {
+	pos := path[len(path)-1].Pos()
+	s := pkg.Scope()
+	for _, node := range path {
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			node = n.Type
+		case *ast.FuncLit:
+			node = n.Type
+		}
+		recorded := scopes[node]
+		if recorded == nil {
+			continue
+		}
+		s = types.NewScope(s, "")
+		for _, name := range recorded.Names() {
+			if obj := recorded.Lookup(name); obj.Pos() < pos {
+				s.Insert(obj)
+			}
+		}
+	}
+	return s
+}
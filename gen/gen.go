@@ -25,9 +25,28 @@ import (
 
 var (
 	defs   map[*ast.Ident]types.Object
+	uses   map[*ast.Ident]types.Object
+	sels   map[*ast.SelectorExpr]*types.Selection
 	_types map[ast.Expr]types.TypeAndValue
 	fs     *token.FileSet
 	pkg    *types.Package
+
+	// scopes is nil when the file being generated is not the one that was type-checked.
+	scopes map[ast.Node]*types.Scope
+
+	// breakpointLines holds the line of each breakpoint in the file being generated.
+	breakpointLines []ast.Expr
+
+	// conditionDecls holds a godebug.DeclareCondition call for each conditional
+	// breakpoint and assertion in the file being generated.
+	conditionDecls []ast.Expr
+
+	// namedTypeDecls holds the names and declarations of the named types in the file being generated.
+	namedTypeDecls []ast.Expr
+
+	// guards holds the Go expression that decides whether to pause at each
+	// conditional breakpoint and assertion in the file being generated.
+	guards map[ast.Node]string
 )
 
 type Config struct {
@@ -37,10 +56,13 @@ type Config struct {
 func Generate(prog *loader.Program, getFileBytes func(string) ([]byte, error), writerFor func(importPath, filename string) io.WriteCloser) {
 	for _, pkgInfo := range prog.InitialPackages() {
 		defs = pkgInfo.Defs
+		uses = pkgInfo.Uses
+		sels = pkgInfo.Selections
 		_types = pkgInfo.Types
 		pkg = pkgInfo.Pkg
 		for _, f := range pkgInfo.Files {
 			fs = prog.Fset
+			scopes = pkgInfo.Scopes
 			fname := fs.Position(f.Pos()).Filename
 			if strings.HasSuffix(fname, "/C") {
 				continue
@@ -52,13 +74,17 @@ func Generate(prog *loader.Program, getFileBytes func(string) ([]byte, error), w
 			}
 			b = normalizeCRLF(b)
 			quotedContents := rawQuote(string(b))
+			namedTypeDecls = namedTypes(fs, fname)
 			ast1, fs1 := parseCgoFile(fname, b)
 			if ast1 != nil {
 				f = ast1
 				fs = fs1
+				scopes = nil
 			}
 			generateGodebugIdentifiers(f)
-			ast.Walk(&visitor{context: f, scopeVar: idents.fileScope}, f)
+			breakpointLines, conditionDecls = nil, nil
+			guards = checkConditions(f)
+			ast.Walk(&visitor{context: f, scopeVar: idents.fileScope, funcState: funcState{funcLits: new(int)}}, f)
 			importName := idents.godebug
 			if importName == "godebug" {
 				importName = ""
@@ -118,6 +144,17 @@ func newSel(selector, name string) *ast.SelectorExpr {
 	}
 }
 
+// sourcePath returns the path the runtime knows the file containing pos by:
+// the import path of its package followed by its base name, so that files
+// with the same name in different packages can be told apart.
+func sourcePath(pos token.Pos) string {
+	base := filepath.Base(fs.Position(pos).Filename)
+	if pkg.Path() == "" {
+		return base
+	}
+	return pkg.Path() + "/" + base
+}
+
 func pos2line(pos token.Pos) (line int) {
 	return fs.Position(pos).Line
 }
@@ -152,7 +189,12 @@ func listNewIdentsFromAssign(assign *ast.AssignStmt) (idents []*ast.Ident) {
 }
 
 func IsBreakpoint(node ast.Node) (b bool) {
-	return isOldBreakpoint(node) || isNewBreakpoint(node)
+	return isOldBreakpoint(node) || isNewBreakpoint(node) || isConditionalBreakpoint(node)
+}
+
+func isConditionalBreakpoint(node ast.Node) bool {
+	_, _, ok := sourceCondition(node)
+	return ok
 }
 
 func isNewBreakpoint(node ast.Node) (b bool) {
@@ -175,23 +217,69 @@ func isOldBreakpoint(node ast.Node) (b bool) {
 	return sel.X.(*ast.Ident).Name == idents.godebug && sel.Sel.Name == "SetTrace"
 }
 
+// logpointPrefix starts the string of a logpoint written in the source, as in
+// _ = "logpoint: id={id}". The rest of the string is the message.
+const logpointPrefix = "logpoint:"
+
+// logpointMessage returns the message of node if it is a logpoint.
+func logpointMessage(node ast.Node) (msg string, ok bool) {
+	s, ok := blankString(node)
+	if !ok || !strings.HasPrefix(s, logpointPrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(s, logpointPrefix)), true
+}
+
+// blankString returns the value of s if node is a statement of the form _ = "s".
+func blankString(node ast.Node) (s string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			s, ok = "", false
+		}
+	}()
+	a := node.(*ast.AssignStmt)
+	if a.Lhs[0].(*ast.Ident).Name != "_" || a.Tok != token.ASSIGN {
+		return "", false
+	}
+	s, err := strconv.Unquote(a.Rhs[0].(*ast.BasicLit).Value)
+	return s, err == nil
+}
+
 type visitor struct {
 	context              ast.Node
 	stmtBuf              []ast.Stmt
 	scopeVar             string
 	blockVars            []*ast.Ident
+	argVars              []*ast.Ident
+	isFuncLit            bool
 	createdExplicitScope bool
 	hasRecovers          bool
 	parentIsExprSwitch   bool
 
+	funcState
 	loopState
 }
 
+// funcState tracks the instrumented function a node is in.
+type funcState struct {
+	// funcName is the qualified name of the function, or empty outside of functions.
+	funcName  string
+	inFuncLit bool
+	// markReturns is set in a function declaration whose return statements are
+	// marked with godebug.Returning. Function literals and functions that call
+	// recover are marked where their body is called instead.
+	markReturns bool
+	// funcLits counts the function literals seen so far in the function, or in
+	// package-level declarations if funcName is empty. It is used to name them
+	// like the Go runtime does: main.main.func1, main.main.func1.1, main.glob..func1.
+	funcLits *int
+}
+
 type loopState struct {
 	newIdents []*ast.Ident
 }
 
-func rewriteFnWithRecovers(body *ast.BlockStmt, fnType *ast.FuncType) (wrapped *ast.FuncLit) {
+func rewriteFnWithRecovers(body *ast.BlockStmt, fnType *ast.FuncType, info []ast.Expr) (wrapped *ast.FuncLit) {
 	// The formatting of the channel declaration is ugly, but it's presented this way here to show how it will look in the actual output.
 	// As far as I know, I would need to set the token.Pos values for the left and right braces of the struct and interface type literals
 	// in order to get them on one line, but I don't think I can do that without computing all of the other token.Pos values for everything
@@ -205,16 +293,16 @@ func rewriteFnWithRecovers(body *ast.BlockStmt, fnType *ast.FuncType) (wrapped *
 		{{%s}}
 		_r := make(chan chan interface {
 		})
-		recovers, panicChan := godebug.EnterFuncWithRecovers(_r, func(ctx *godebug.Context) {
+		recovers, panicChan := godebug.EnterFuncWithRecovers(%s, _r, func(ctx *godebug.Context) {
 			%s
-		})
+		}{{, %s}})
 		for recoverChan := range recovers {
 			recoverChan <- recover()
 		}
 		if panicVal, ok := <-panicChan; ok {
 			panic(panicVal)
 		}
-		{{return %s}}`, outputDecls, body.List, outputs)
+		{{return %s}}`, outputDecls, info, body.List, addressesOf(outputs), outputs)
 	body.Rbrace = token.NoPos // without this I was getting extra whitespace at the end of the function
 	return wrapped
 }
@@ -263,7 +351,92 @@ func inputsOrOutputs(fieldList *ast.FieldList, prefix string) (decl []ast.Stmt,
 	return decl, all
 }
 
-func genEnterFunc(fn *ast.FuncDecl, inputs, outputs []ast.Expr) (stmts []ast.Stmt) {
+// funcDeclName returns the qualified name of fn as the Go runtime would report it,
+// like main.main, main.T.Value or main.(*T).Pointer.
+func funcDeclName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil {
+		return pkgPrefix() + fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if paren, ok := recv.(*ast.ParenExpr); ok {
+		recv = paren.X
+	}
+	if star, ok := recv.(*ast.StarExpr); ok {
+		return pkgPrefix() + "(*" + exprString(star.X) + ")." + fn.Name.Name
+	}
+	return pkgPrefix() + exprString(recv) + "." + fn.Name.Name
+}
+
+// pkgPrefix returns the prefix of qualified names in the current package.
+// As in the Go runtime, functions in package main are named main.f
+// rather than by their import path.
+func pkgPrefix() string {
+	if pkg.Name() == "main" {
+		return "main."
+	}
+	return pkg.Path() + "."
+}
+
+// funcLitName returns the name of the next function literal in v's function.
+func (v *visitor) funcLitName() string {
+	*v.funcLits++
+	n := strconv.Itoa(*v.funcLits)
+	switch {
+	case v.funcName == "":
+		return pkgPrefix() + "glob..func" + n
+	case v.inFuncLit:
+		return v.funcName + "." + n
+	}
+	return v.funcName + ".func" + n
+}
+
+// funcInfo returns the arguments that identify the function v is in to EnterFunc and its relatives.
+func (v *visitor) funcInfo() []ast.Expr {
+	return []ast.Expr{
+		newStringLit(strconv.Quote(v.funcName)),
+		newStringLit(strconv.Quote(sourcePath(v.context.Pos()))),
+	}
+}
+
+func exprString(e ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, token.NewFileSet(), e)
+	return buf.String()
+}
+
+// nameResults gives a name to each unnamed or blank result in fieldList,
+// so that the function's return values can be passed to ExitFunc.
+// It returns all of the results.
+func nameResults(fieldList *ast.FieldList) (all []ast.Expr) {
+	if fieldList == nil {
+		return
+	}
+	count := 1
+	for _, field := range fieldList.List {
+		if field.Names == nil {
+			field.Names = []*ast.Ident{blank}
+		}
+		for i, name := range field.Names {
+			if name.Name == "_" {
+				field.Names[i] = ast.NewIdent(idents.result + strconv.Itoa(count))
+			}
+			count++
+			all = append(all, field.Names[i])
+		}
+	}
+	return all
+}
+
+// addressesOf returns &x for each x in exprs.
+func addressesOf(exprs []ast.Expr) []ast.Expr {
+	addrs := make([]ast.Expr, len(exprs))
+	for i, x := range exprs {
+		addrs[i] = &ast.UnaryExpr{Op: token.AND, X: x}
+	}
+	return addrs
+}
+
+func genEnterFunc(fn *ast.FuncDecl, info, inputs, outputs []ast.Expr) (stmts []ast.Stmt) {
 	var (
 		pseudoIdent ast.Expr = fn.Name
 		recvType    ast.Expr
@@ -292,25 +465,25 @@ func genEnterFunc(fn *ast.FuncDecl, inputs, outputs []ast.Expr) (stmts []ast.Stm
 	if len(inputs) == 0 && len(outputs) == 0 {
 		return astPrintf(`
 		{{var receiver %s}}
-		ctx, ok := godebug.EnterFunc(%s)
+		ctx, ok := godebug.EnterFunc(%s, %s)
 		if !ok {
 			return
 		}`,
-			recvType, pseudoIdent)
+			recvType, info, pseudoIdent)
 	}
 
 	return astPrintf(`
 			{{var receiver %s}}
-			ctx, ok := godebug.EnterFunc(func() {
+			ctx, ok := godebug.EnterFunc(%s, func() {
 				{{%s =}} %s(%s%s)
 			})
 			if !ok {
 				return %s
 			}`,
-		recvType, outputs, pseudoIdent, inputs, ellipsis, outputs)
+		recvType, info, outputs, pseudoIdent, inputs, ellipsis, outputs)
 }
 
-func genEnterFuncLit(fnType *ast.FuncType, body *ast.BlockStmt, hasRecovers bool) *ast.BlockStmt {
+func genEnterFuncLit(fnType *ast.FuncType, body *ast.BlockStmt, info []ast.Expr, hasRecovers bool) *ast.BlockStmt {
 	fn := createConflictFreeName("fn", fnType, false)
 	decl, outputs := inputsOrOutputs(fnType.Results, idents.result)
 	deferCloseQuit := ""
@@ -327,23 +500,25 @@ func genEnterFuncLit(fnType *ast.FuncType, body *ast.BlockStmt, hasRecovers bool
 						%s
 					}()
 				}
-				if ctx, ok := godebug.EnterFuncLit(%s); ok {
-					defer godebug.ExitFunc(ctx)
+				if ctx, ok := godebug.EnterFuncLit(%s, %s); ok {
+					defer godebug.ExitFunc(ctx, %s)
 					%s(ctx)
+					godebug.Returning(ctx)
 				}
 				return %s
-			`, deferCloseQuit, decl, fn, outputs, fnType.Results, body.List, fn, fn, outputs)
+			`, deferCloseQuit, decl, fn, outputs, fnType.Results, body.List, info, fn, addressesOf(outputs), fn, outputs)
 	} else {
 		newBody.List = astPrintf(`
 				{{%s}}
 				%s := func(ctx *godebug.Context) {
 					%s
 				}
-				if ctx, ok := godebug.EnterFuncLit(%s); ok {
+				if ctx, ok := godebug.EnterFuncLit(%s, %s); ok {
 					defer godebug.ExitFunc(ctx)
 					%s(ctx)
+					godebug.Returning(ctx)
 				}
-				`, deferCloseQuit, fn, body.List, fn, fn)
+				`, deferCloseQuit, fn, body.List, info, fn, fn)
 	}
 	return newBody
 }
@@ -403,30 +578,32 @@ func (v *visitor) finalizeNode() {
 			break
 		}
 		if v.hasRecovers {
-			rewriteFnWithRecovers(i.Body, i.Type)
+			rewriteFnWithRecovers(i.Body, i.Type, v.funcInfo())
 			break
 		}
-		declOuts, outputs := inputsOrOutputs(i.Type.Results, idents.result)
-		declIns, inputs := inputsOrOutputs(i.Type.Params, idents.input)
-		prepend := append(declIns, declOuts...)
+		outputs := nameResults(i.Type.Results)
+		prepend, inputs := inputsOrOutputs(i.Type.Params, idents.input)
 		// We will refer to this function by name when we call genEnterFunc. If any of the
 		// parameters have the same name as the function, they will conflict. To get around that,
 		// rename any such parameters now.
 		rewriteConflictingNames(i)
-		prepend = append(prepend, genEnterFunc(i, inputs, outputs)...)
-		if !(pkg.Name() == "main" && i.Name.Name == "main") {
-			prepend = append(prepend, &ast.DeferStmt{
-				Call: newCall(idents.godebug, "ExitFunc", ast.NewIdent(idents.ctx)),
-			})
-		}
+		prepend = append(prepend, genEnterFunc(i, v.funcInfo(), inputs, outputs)...)
+		// main.main gets an ExitFunc too, even though it never returns to instrumented
+		// code, so that the debugger can catch panics that pass through it.
+		prepend = append(prepend, &ast.DeferStmt{
+			Call: newCall(idents.godebug, "ExitFunc", append([]ast.Expr{ast.NewIdent(idents.ctx)}, addressesOf(outputs)...)...),
+		})
 
 		i.Body.List = append(prepend, i.Body.List...)
+		if _, ok := i.Body.List[len(i.Body.List)-1].(*ast.ReturnStmt); !ok && i.Type.Results == nil {
+			i.Body.List = append(i.Body.List, newCallStmt(idents.godebug, "Returning", ast.NewIdent(idents.ctx)))
+		}
 
 	case *ast.FuncLit:
 		if v.hasRecovers {
-			rewriteFnWithRecovers(i.Body, i.Type)
+			rewriteFnWithRecovers(i.Body, i.Type, v.funcInfo())
 		} else {
-			i.Body = genEnterFuncLit(i.Type, i.Body, v.hasRecovers)
+			i.Body = genEnterFuncLit(i.Type, i.Body, v.funcInfo(), v.hasRecovers)
 		}
 
 	case *ast.BlockStmt:
@@ -497,8 +674,20 @@ func (v *visitor) finalizeNode() {
 		}
 		newDecls = append(newDecls, varDecl(&ast.ValueSpec{
 			Names:  []*ast.Ident{ast.NewIdent(idents.fileScope)},
-			Values: []ast.Expr{newCall(idents.godebug, "EnteringNewScope", ast.NewIdent(idents.fileContents))},
+			Values: []ast.Expr{newCall(idents.godebug, "EnteringNewScope", append([]ast.Expr{ast.NewIdent(idents.fileContents), newStringLit(strconv.Quote(sourcePath(i.Pos())))}, breakpointLines...)...)},
 		}))
+		for _, call := range conditionDecls {
+			newDecls = append(newDecls, varDecl(&ast.ValueSpec{
+				Names:  []*ast.Ident{ast.NewIdent("_")},
+				Values: []ast.Expr{call},
+			}))
+		}
+		if len(namedTypeDecls) > 0 {
+			newDecls = append(newDecls, varDecl(&ast.ValueSpec{
+				Names:  []*ast.Ident{ast.NewIdent("_")},
+				Values: []ast.Expr{newCall(idents.godebug, "DeclareTypes", append([]ast.Expr{newStringLit(strconv.Quote(pkg.Name()))}, namedTypeDecls...)...)},
+			}))
+		}
 		i.Decls = append(newDecls, i.Decls...)
 	}
 }
@@ -515,7 +704,7 @@ func stopAtBlockIn(node ast.Node) bool {
 }
 
 func (v *visitor) Visit(node ast.Node) ast.Visitor {
-	childVisitor := &visitor{context: node, scopeVar: v.scopeVar, parentIsExprSwitch: v.parentIsExprSwitch}
+	childVisitor := &visitor{context: node, scopeVar: v.scopeVar, parentIsExprSwitch: v.parentIsExprSwitch, funcState: v.funcState}
 
 	switch i := node.(type) {
 
@@ -530,14 +719,21 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 			return nil
 		}
 		// If there is a call to recover() anywhere in this function, it needs some fairly elaborate treatment.
-		childVisitor.blockVars = getIdents(i.Recv, i.Type.Params, i.Type.Results)
 		childVisitor.hasRecovers = rewriteRecoversIn(i.Body)
+		childVisitor.funcState = funcState{funcName: funcDeclName(i), funcLits: new(int), markReturns: !childVisitor.hasRecovers}
+		childVisitor.argVars = getIdents(i.Recv, i.Type.Params)
+		childVisitor.blockVars = getIdents(i.Type.Results)
+		rewriteExitsIn(i.Body)
 		return childVisitor
 
 	case *ast.FuncLit:
+		childVisitor.funcState = funcState{funcName: v.funcLitName(), inFuncLit: true, funcLits: new(int)}
 		// If there is a call to recover() anywhere in this function, it needs some fairly elaborate treatment.
-		childVisitor.blockVars = getIdents(i.Type.Params, i.Type.Results)
+		childVisitor.argVars = getIdents(i.Type.Params)
+		childVisitor.blockVars = getIdents(i.Type.Results)
+		childVisitor.isFuncLit = true
 		childVisitor.hasRecovers = rewriteRecoversIn(i.Body)
+		rewriteExitsIn(i.Body)
 		return childVisitor
 
 	case *ast.BlockStmt:
@@ -548,8 +744,15 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 			v.stmtBuf = append(v.stmtBuf, i)
 		}
 		childVisitor.stmtBuf = make([]ast.Stmt, 0, 3*len(i.List))
-		if len(v.blockVars) > 0 {
+		// A function literal's scope is a child of the scope it is declared in, so it always
+		// declares its arguments, even if it has none, to mark where its own scope begins.
+		if len(v.argVars) > 0 || v.isFuncLit || len(v.blockVars) > 0 {
 			childVisitor.createScope()
+		}
+		if len(v.argVars) > 0 || v.isFuncLit {
+			childVisitor.stmtBuf = append(childVisitor.stmtBuf, newDeclareArgsCall(childVisitor.scopeVar, v.argVars))
+		}
+		if len(v.blockVars) > 0 {
 			childVisitor.stmtBuf = append(childVisitor.stmtBuf, newDeclareCall(childVisitor.scopeVar, v.blockVars))
 		}
 		return childVisitor
@@ -650,16 +853,39 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 		}
 	}
 
+	if isTestFailure(node) {
+		v.stmtBuf = append(v.stmtBuf, newCallStmt(idents.godebug, "TestFailure", ast.NewIdent(idents.ctx)))
+	}
+	msg, isLogpoint := logpointMessage(node)
+	if isLogpoint {
+		v.stmtBuf = append(v.stmtBuf, newCallStmt(idents.godebug, "Logpoint", ast.NewIdent(idents.ctx), newStringLit(strconv.Quote(msg))))
+	}
 	if !IsBreakpoint(node) {
 		v.stmtBuf = append(v.stmtBuf, newCallStmt(idents.godebug, "Line", ast.NewIdent(idents.ctx), ast.NewIdent(v.scopeVar), newInt(pos2line(node.Pos()))))
 	}
 
+	// Tell ExitFunc that the function is not panicking, unless the results may still panic.
+	if ret, ok := node.(*ast.ReturnStmt); ok && v.markReturns && cannotPanic(ret.Results...) {
+		v.stmtBuf = append(v.stmtBuf, newCallStmt(idents.godebug, "Returning", ast.NewIdent(idents.ctx)))
+	}
+
 	// Copy the statement into the new block we are building.
 	if stmt, ok := node.(ast.Stmt); ok {
 		if IsBreakpoint(node) {
 			// Rewrite `godebug.SetTrace()` and `_ = "breakpoint"` as `godebug.SetTraceGen(ctx)`.
-			v.stmtBuf = append(v.stmtBuf, astPrintf("godebug.SetTraceGen(ctx)")[0], newCallStmt(idents.godebug, "Line", ast.NewIdent(idents.ctx), ast.NewIdent(v.scopeVar), newInt(pos2line(node.Pos()))))
-		} else {
+			breakpointLines = append(breakpointLines, newInt(pos2line(node.Pos())))
+			if cond, assert, ok := sourceCondition(node); ok {
+				conditionDecls = append(conditionDecls, newCall(idents.godebug, "DeclareCondition",
+					ast.NewIdent(idents.fileScope), newInt(pos2line(node.Pos())), newStringLit(strconv.Quote(cond)), ast.NewIdent(strconv.FormatBool(assert))))
+			}
+			setTrace := astPrintf("godebug.SetTraceGen(ctx)")[0]
+			if guard, ok := guards[node]; ok {
+				// Conditions are compiled, so that they cost no more than an if statement.
+				setTrace = astPrintf("if %s { godebug.SetTraceGen(ctx) }", guard)[0]
+			}
+			v.stmtBuf = append(v.stmtBuf, setTrace, newCallStmt(idents.godebug, "Line", ast.NewIdent(idents.ctx), ast.NewIdent(v.scopeVar), newInt(pos2line(node.Pos()))))
+		} else if !isLogpoint {
+			// Logpoints are dropped, since Line prints their messages.
 			v.stmtBuf = append(v.stmtBuf, stmt)
 		}
 	}
@@ -722,34 +948,35 @@ func getIdents(lists ...*ast.FieldList) (idents []*ast.Ident) {
 }
 
 func newDeclareCall(scopeVar string, newVars []*ast.Ident) ast.Stmt {
-	return newIdentsCall(scopeVar, newVars, false)
+	return newIdentsCall(scopeVar, "Declare", newVars, false)
+}
+
+func newDeclareArgsCall(scopeVar string, args []*ast.Ident) ast.Stmt {
+	return newIdentsCall(scopeVar, "DeclareArgs", args, false)
 }
 
 func newConstantCall(scopeVar string, newConsts []*ast.Ident) ast.Stmt {
-	return newIdentsCall(scopeVar, newConsts, true)
+	return newIdentsCall(scopeVar, "Constant", newConsts, true)
 }
 
-func newIdentsCall(scopeVar string, newIdents []*ast.Ident, isConst bool) ast.Stmt {
+func newIdentsCall(scopeVar, f string, newIdents []*ast.Ident, isConst bool) ast.Stmt {
 	if scopeVar == "" {
 		scopeVar = idents.scope
 	}
-	f := "Declare"
-	if isConst {
-		f = "Constant"
-	}
 	expr := newCallStmt(scopeVar, f)
 	call := expr.X.(*ast.CallExpr)
-	call.Args = make([]ast.Expr, 2*len(newIdents))
+	call.Args = make([]ast.Expr, 3*len(newIdents))
 	for i, ident := range newIdents {
-		// Quoted identifier name.
-		call.Args[2*i] = newStringLit(strconv.Quote(ident.Name))
+		// Quoted identifier name and declared type.
+		call.Args[3*i] = newStringLit(strconv.Quote(ident.Name))
+		call.Args[3*i+1] = newStringLit(strconv.Quote(declaredType(ident)))
 
 		if isConst {
 			// Pass the value if constant.
-			call.Args[2*i+1] = ident
+			call.Args[3*i+2] = ident
 		} else {
 			// Pass a pointer if variable.
-			call.Args[2*i+1] = &ast.UnaryExpr{
+			call.Args[3*i+2] = &ast.UnaryExpr{
 				Op: token.AND,
 				X:  ident,
 			}
@@ -953,6 +1180,125 @@ func rewriteRecoverCall(parent, _recover ast.Node) {
 	}
 }
 
+// exitHooks maps the functions that end the program to the functions in the
+// godebug library that their calls are routed through, so that the debugger can
+// pause before the program exits. The log functions stand for the methods of
+// log.Logger with the same names as well.
+var exitHooks = map[string]string{
+	"os.Exit":     "Exit",
+	"log.Fatal":   "Fatal",
+	"log.Fatalln": "Fatal",
+	"log.Fatalf":  "Fatalf",
+}
+
+// rewriteExitsIn rewrites calls like os.Exit(code) in block, other than those in nested
+// function literals, to godebug.Exit(ctx, os.Exit, code).
+func rewriteExitsIn(block *ast.BlockStmt) {
+	ast.Inspect(block, func(node ast.Node) bool {
+		switch x := node.(type) {
+		case *ast.CallExpr:
+			if hook := exitHook(x.Fun); hook != "" {
+				sel := newSel(idents.godebug, hook)
+				sel.X.(*ast.Ident).NamePos = x.Fun.Pos()
+				x.Args = append([]ast.Expr{ast.NewIdent(idents.ctx), x.Fun}, x.Args...)
+				x.Fun = sel
+			}
+		case *ast.FuncLit:
+			return false
+		}
+		return true
+	})
+}
+
+func exitHook(fn ast.Expr) string {
+	sel, ok := fn.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	f, ok := uses[sel.Sel].(*types.Func)
+	if !ok || f.Pkg() == nil {
+		return ""
+	}
+	return exitHooks[f.Pkg().Path()+"."+f.Name()]
+}
+
+// cannotPanic reports whether evaluating exprs cannot panic, as when
+// they are names, literals, or arithmetic on them that does not divide or shift.
+func cannotPanic(exprs ...ast.Expr) bool {
+	for _, e := range exprs {
+		switch e := e.(type) {
+		case *ast.Ident, *ast.BasicLit, *ast.FuncLit:
+		case *ast.ParenExpr:
+			if !cannotPanic(e.X) {
+				return false
+			}
+		case *ast.UnaryExpr:
+			if e.Op == token.ARROW || !cannotPanic(e.X) {
+				return false
+			}
+		case *ast.BinaryExpr:
+			switch e.Op {
+			case token.QUO, token.REM, token.SHL, token.SHR:
+				return false
+			}
+			if !cannotPanic(e.X, e.Y) {
+				return false
+			}
+		case *ast.CompositeLit:
+			if !cannotPanic(e.Elts...) {
+				return false
+			}
+		case *ast.KeyValueExpr:
+			if !cannotPanic(e.Key, e.Value) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// failMethods are the methods of testing.T and testing.B that fail the test.
+var failMethods = map[string]bool{
+	"Error":  true,
+	"Errorf": true,
+	"Fatal":  true,
+	"Fatalf": true,
+	"Fail":   true,
+}
+
+// isTestFailure reports whether node is a statement that calls a method that
+// fails the test on a *testing.T or *testing.B.
+func isTestFailure(node ast.Node) bool {
+	stmt, ok := node.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := stmt.X.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || !failMethods[sel.Sel.Name] {
+		return false
+	}
+	s, ok := sels[sel]
+	if !ok || s.Kind() != types.MethodVal || s.Obj().Pkg() == nil || s.Obj().Pkg().Path() != "testing" {
+		return false
+	}
+	ptr, ok := s.Recv().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "testing" && (obj.Name() == "T" || obj.Name() == "B")
+}
+
 func parseCgoFile(filename string, bytes []byte) (*ast.File, *token.FileSet) {
 	fs := token.NewFileSet()
 	ast1, err := parser.ParseFile(fs, filename, bytes, parser.ParseComments)
@@ -0,0 +1,230 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/mailgun/godebug/Godeps/_workspace/src/golang.org/x/tools/go/types"
+)
+
+// declaredType returns the type of the variable or constant ident declares,
+// as it would be written in the package being generated, or "" if it is not known.
+func declaredType(ident *ast.Ident) string {
+	obj := defs[ident]
+	if obj == nil {
+		return ""
+	}
+	return typeString(obj.Type())
+}
+
+// typeString is like types.TypeString relative to pkg, but qualifies types
+// from other packages with the package name, as the runtime does, instead
+// of the import path. The vendored go/types cannot be told how to qualify
+// types, so typeString writes the types that may contain named ones itself.
+func typeString(t types.Type) string {
+	var buf bytes.Buffer
+	writeType(&buf, t)
+	return buf.String()
+}
+
+func writeType(buf *bytes.Buffer, t types.Type) {
+	switch t := t.(type) {
+	case *types.Named:
+		if p := t.Obj().Pkg(); p != nil && p != pkg {
+			buf.WriteString(p.Name())
+			buf.WriteByte('.')
+		}
+		buf.WriteString(t.Obj().Name())
+	case *types.Array:
+		fmt.Fprintf(buf, "[%d]", t.Len())
+		writeType(buf, t.Elem())
+	case *types.Slice:
+		buf.WriteString("[]")
+		writeType(buf, t.Elem())
+	case *types.Pointer:
+		buf.WriteByte('*')
+		writeType(buf, t.Elem())
+	case *types.Map:
+		buf.WriteString("map[")
+		writeType(buf, t.Key())
+		buf.WriteByte(']')
+		writeType(buf, t.Elem())
+	case *types.Chan:
+		parens := false
+		switch t.Dir() {
+		case types.SendRecv:
+			buf.WriteString("chan ")
+			// chan (<-chan T) needs the parentheses.
+			c, ok := t.Elem().(*types.Chan)
+			parens = ok && c.Dir() == types.RecvOnly
+		case types.SendOnly:
+			buf.WriteString("chan<- ")
+		case types.RecvOnly:
+			buf.WriteString("<-chan ")
+		}
+		if parens {
+			buf.WriteByte('(')
+		}
+		writeType(buf, t.Elem())
+		if parens {
+			buf.WriteByte(')')
+		}
+	case *types.Struct:
+		buf.WriteString("struct{")
+		for i := 0; i < t.NumFields(); i++ {
+			if i > 0 {
+				buf.WriteString("; ")
+			}
+			f := t.Field(i)
+			if !f.Anonymous() {
+				buf.WriteString(f.Name() + " ")
+			}
+			writeType(buf, f.Type())
+			if tag := t.Tag(i); tag != "" {
+				fmt.Fprintf(buf, " %q", tag)
+			}
+		}
+		buf.WriteByte('}')
+	case *types.Interface:
+		buf.WriteString("interface{")
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			if i > 0 {
+				buf.WriteString("; ")
+			}
+			m := t.ExplicitMethod(i)
+			buf.WriteString(m.Name())
+			writeSignature(buf, m.Type().(*types.Signature))
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if i > 0 || t.NumExplicitMethods() > 0 {
+				buf.WriteString("; ")
+			}
+			writeType(buf, t.Embedded(i))
+		}
+		buf.WriteByte('}')
+	case *types.Signature:
+		buf.WriteString("func")
+		writeSignature(buf, t)
+	case *types.Tuple:
+		writeTuple(buf, t, false)
+	default:
+		// Basic types need no qualifying.
+		buf.WriteString(types.TypeString(pkg, t))
+	}
+}
+
+// writeSignature writes sig without the leading func keyword.
+func writeSignature(buf *bytes.Buffer, sig *types.Signature) {
+	writeTuple(buf, sig.Params(), sig.Variadic())
+	results := sig.Results()
+	switch {
+	case results.Len() == 0:
+	case results.Len() == 1 && results.At(0).Name() == "":
+		buf.WriteByte(' ')
+		writeType(buf, results.At(0).Type())
+	default:
+		buf.WriteByte(' ')
+		writeTuple(buf, results, false)
+	}
+}
+
+func writeTuple(buf *bytes.Buffer, tup *types.Tuple, variadic bool) {
+	buf.WriteByte('(')
+	for i := 0; i < tup.Len(); i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		v := tup.At(i)
+		if v.Name() != "" {
+			buf.WriteString(v.Name() + " ")
+		}
+		if s, ok := v.Type().(*types.Slice); ok && variadic && i == tup.Len()-1 {
+			buf.WriteString("...")
+			writeType(buf, s.Elem())
+			continue
+		}
+		writeType(buf, v.Type())
+	}
+	buf.WriteByte(')')
+}
+
+// namedTypes returns the name and declaration of each package-level named type
+// declared in the file called filename, for the ptype command, as arguments to
+// godebug.DeclareTypes.
+func namedTypes(fset *token.FileSet, filename string) (args []ast.Expr) {
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || fset.Position(obj.Pos()).Filename != filename {
+			continue
+		}
+		args = append(args, newStringLit(strconv.Quote(name)), newStringLit(rawQuote(typeDecl(obj))))
+	}
+	return args
+}
+
+// typeDecl returns the declaration of the named type obj, with one line for each
+// field or interface method, followed by the signatures of its methods.
+func typeDecl(obj *types.TypeName) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "type %s ", obj.Name())
+	switch u := obj.Type().Underlying().(type) {
+	case *types.Struct:
+		if u.NumFields() == 0 {
+			buf.WriteString("struct{}")
+			break
+		}
+		buf.WriteString("struct {\n")
+		w := tabwriter.NewWriter(&buf, 0, 4, 1, ' ', 0)
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if f.Anonymous() {
+				fmt.Fprintf(w, "    %s\n", typeString(f.Type()))
+			} else {
+				fmt.Fprintf(w, "    %s\t%s\n", f.Name(), typeString(f.Type()))
+			}
+		}
+		w.Flush()
+		buf.WriteString("}")
+	case *types.Interface:
+		if u.NumExplicitMethods() == 0 && u.NumEmbeddeds() == 0 {
+			buf.WriteString("interface{}")
+			break
+		}
+		buf.WriteString("interface {\n")
+		for i := 0; i < u.NumEmbeddeds(); i++ {
+			fmt.Fprintf(&buf, "    %s\n", typeString(u.Embedded(i)))
+		}
+		for i := 0; i < u.NumExplicitMethods(); i++ {
+			m := u.ExplicitMethod(i)
+			fmt.Fprintf(&buf, "    %s%s\n", m.Name(), signatureString(m.Type().(*types.Signature)))
+		}
+		buf.WriteString("}")
+	default:
+		buf.WriteString(typeString(u))
+	}
+	if named, ok := obj.Type().(*types.Named); ok && named.NumMethods() > 0 {
+		buf.WriteString("\n")
+		for i := 0; i < named.NumMethods(); i++ {
+			m := named.Method(i)
+			sig := m.Type().(*types.Signature)
+			recv := typeString(sig.Recv().Type())
+			if name := sig.Recv().Name(); name != "" && name != "_" {
+				recv = name + " " + recv
+			}
+			fmt.Fprintf(&buf, "\nfunc (%s) %s%s", recv, m.Name(), signatureString(sig))
+		}
+	}
+	return buf.String()
+}
+
+// signatureString returns sig without the leading func keyword.
+func signatureString(sig *types.Signature) string {
+	var buf bytes.Buffer
+	writeSignature(&buf, sig)
+	return buf.String()
+}
@@ -0,0 +1,732 @@
+package godebug
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+// files maps the path of every instrumented source file, as the generated code
+// gives it to EnteringNewScope, to its lines. The path is the import path of the
+// file's package followed by its base name. Files register themselves when their
+// file-level Scope is created.
+var (
+	filesMu sync.Mutex
+	files   = make(map[string][]string)
+)
+
+func registerFile(s *Scope) {
+	filesMu.Lock()
+	files[s.file] = s.fileText
+	filesMu.Unlock()
+}
+
+// matchFiles returns the paths of the instrumented files that name refers to,
+// which are those whose path is name or ends in "/" + name. The caller must hold filesMu.
+func matchFiles(name string) []string {
+	var matches []string
+	for file := range files {
+		if file == name || strings.HasSuffix(file, "/"+name) {
+			matches = append(matches, file)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// lookupFile returns the path and lines of the instrumented file that name refers to.
+func lookupFile(name string) (file string, lines []string, err error) {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	switch matches := matchFiles(name); len(matches) {
+	case 0:
+		return "", nil, fmt.Errorf("There is no instrumented file named %q.", name)
+	case 1:
+		return matches[0], files[matches[0]], nil
+	default:
+		return "", nil, fmt.Errorf("%s is ambiguous. It could be %s. Give more of its path.", name, strings.Join(matches, " or "))
+	}
+}
+
+// shortName returns the shortest end of the path of the instrumented file
+// that tells it apart from the others, which is usually its base name.
+func shortName(file string) string {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	for i := strings.LastIndex(file, "/"); i >= 0; i = strings.LastIndex(file[:i], "/") {
+		if len(matchFiles(file[i+1:])) <= 1 {
+			return file[i+1:]
+		}
+	}
+	return file
+}
+
+// breakEnvVar is set by the godebug command when it is given -break flags.
+// It holds the argument of each flag on its own line.
+const breakEnvVar = "GODEBUG_BREAK"
+
+func init() {
+	for _, spec := range strings.Split(os.Getenv(breakEnvVar), "\n") {
+		if spec != "" {
+			setBreakpoint(nil, 0, spec, false)
+		}
+	}
+}
+
+// A location is a line in an instrumented source file.
+type location struct {
+	file string
+	line int
+}
+
+func (l location) String() string {
+	return shortName(l.file) + ":" + strconv.Itoa(l.line)
+}
+
+// A breakpoint is either written in the source as _ = "breakpoint", set at a
+// line from the prompt with the break command or, if fn is not nil, set on entry
+// to the functions fn matches. Its mutable fields are guarded by breakpointsMu.
+type breakpoint struct {
+	id     int
+	loc    location
+	fn     *funcPattern
+	source bool
+
+	// If message is not nil, the breakpoint is a logpoint, set with the log
+	// command. The debugger prints the message there instead of pausing.
+	message *logMessage
+
+	// If cond is not nil, the debugger only pauses at the
+	// breakpoint when cond evaluates to true.
+	cond     ast.Expr
+	condText string
+
+	// assert is set for assertions written in the source, as in
+	// _ = "assert: len(queue) < 100". The generated code evaluates
+	// their conditions, and those of conditional source breakpoints.
+	assert bool
+
+	disabled  bool
+	temporary bool // deleted the first time the debugger pauses at it
+	ignore    int  // the number of hits to pass over before pausing
+	hits      int
+}
+
+func (b *breakpoint) String() string {
+	s := b.where()
+	if b.message != nil {
+		s += " " + b.message.String()
+	}
+	switch {
+	case b.assert:
+		s += " assert " + b.condText
+	case b.condText != "":
+		s += " if " + b.condText
+	}
+	return s
+}
+
+func (b *breakpoint) where() string {
+	if b.fn != nil {
+		return b.fn.text
+	}
+	return b.loc.String()
+}
+
+// description describes b, as in "at file.go:12 if x > 3" or "on main.f".
+func (b *breakpoint) description() string {
+	return b.preposition() + " " + b.String()
+}
+
+func (b *breakpoint) kind() string {
+	if b.message != nil {
+		return "logpoint"
+	}
+	return "breakpoint"
+}
+
+func (b *breakpoint) preposition() string {
+	if b.fn != nil {
+		return "on"
+	}
+	return "at"
+}
+
+// A funcPattern matches qualified function names, like main.(*T).Method or
+// main.main.func1. It is either a name, which may leave off any leading part
+// of the package path and receiver, or a regular expression in single quotes.
+type funcPattern struct {
+	text string
+	re   *regexp.Regexp // nil if text is a name
+}
+
+func parseFuncPattern(text string) (*funcPattern, error) {
+	p := &funcPattern{text: text}
+	if strings.HasPrefix(text, "'") {
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("The regular expression %s is missing its closing quote.", text)
+		}
+		re, err := regexp.Compile(text[1 : len(text)-1])
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse the regular expression %s: %v", text, err)
+		}
+		p.re = re
+	}
+	return p, nil
+}
+
+func (p *funcPattern) match(name string) bool {
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	return name == p.text || strings.HasSuffix(name, "."+p.text) || strings.HasSuffix(name, "/"+p.text)
+}
+
+var (
+	breakpointsMu     sync.Mutex
+	lastBreakpoint    int
+	breakpoints       = make(map[location]*breakpoint) // set at lines from the prompt
+	logpoints         = make(map[location]*breakpoint)
+	sourceBreakpoints = make(map[location]*breakpoint)
+	funcBreakpoints   []*breakpoint // including logpoints on functions
+
+	// breakpointCount mirrors len(breakpoints) + len(logpoints) + len(funcBreakpoints)
+	// so that Line does not need to take a lock when there are none. Source
+	// breakpoints are not counted, since SetTraceGen marks the lines they are on.
+	breakpointCount int32
+)
+
+func updateBreakpointCount() {
+	atomic.StoreInt32(&breakpointCount, int32(len(breakpoints)+len(logpoints)+len(funcBreakpoints)))
+}
+
+func registerSourceBreakpoints(s *Scope, lines []int) {
+	breakpointsMu.Lock()
+	defer breakpointsMu.Unlock()
+	for _, line := range lines {
+		lastBreakpoint++
+		l := location{s.file, line}
+		sourceBreakpoints[l] = &breakpoint{id: lastBreakpoint, loc: l, source: true}
+	}
+}
+
+// DeclareCondition records cond, the condition of the breakpoint written in the
+// source of s at line, as in _ = "breakpoint if x > 10", or of the assertion there
+// if assert is set. The generated code checks the condition itself. DeclareCondition
+// returns true so that generated code can call it in a package-level variable declaration.
+func DeclareCondition(s *Scope, line int, cond string, assert bool) bool {
+	breakpointsMu.Lock()
+	defer breakpointsMu.Unlock()
+	if b := sourceBreakpoints[location{s.file, line}]; b != nil {
+		b.condText, b.assert = cond, assert
+	}
+	return true
+}
+
+// hitBreakpoint reports whether the debugger should pause at line because of a
+// breakpoint. first is true if line is the first line to run in c's frame, where
+// function breakpoints apply, and source is true if SetTraceGen marked the line.
+// Unlike shouldPause, it is consulted even while the debugger is in state run. If
+// the debugger is not following any goroutine, the goroutine c belongs to becomes
+// the one it follows. If it is following a different goroutine, the breakpoint
+// is reported at the next pause instead. Logpoints print their messages whichever
+// goroutine reaches them, and never pause.
+func hitBreakpoint(c *Context, s *Scope, line int, first, source bool) bool {
+	if atomic.LoadInt32(&breakpointCount) == 0 && !source {
+		return false
+	}
+	loc := location{s.file, line}
+	breakpointsMu.Lock()
+	var candidates []*breakpoint
+	if b := sourceBreakpoints[loc]; b != nil && source {
+		candidates = append(candidates, b)
+	}
+	if b := breakpoints[loc]; b != nil {
+		candidates = append(candidates, b)
+	}
+	if b := logpoints[loc]; b != nil {
+		candidates = append(candidates, b)
+	}
+	if first {
+		for _, b := range funcBreakpoints {
+			if b.fn.match(c.frame.fn) {
+				candidates = append(candidates, b)
+			}
+		}
+	}
+	breakpointsMu.Unlock()
+	var reached []*breakpoint
+	for _, b := range candidates {
+		switch {
+		case !b.reached(s):
+		case b.message != nil:
+			printLog(loc, s, b.message)
+		default:
+			reached = append(reached, b)
+		}
+	}
+	if len(reached) == 0 {
+		return false
+	}
+	if atomic.CompareAndSwapInt32(&currentState, run, step) {
+		atomic.StoreUint32(&currentGoroutine, c.goroutine)
+	} else if atomic.LoadUint32(&currentGoroutine) != c.goroutine {
+		recordMissed(c, loc)
+		return false
+	}
+	entered := false
+	for _, b := range reached {
+		if b.fn != nil && !entered {
+			fmt.Printf("Entered %s.\n", c.frame.fn)
+			entered = true
+		}
+		if b.assert {
+			fmt.Printf("Assertion failed: %s\n", b.condText)
+		}
+		if b.temporary {
+			breakpointsMu.Lock()
+			removeBreakpoint(b)
+			breakpointsMu.Unlock()
+			fmt.Printf("Deleted temporary breakpoint %d %s.\n", b.id, b.description())
+		}
+	}
+	return true
+}
+
+// reached records that the program reached b, and reports whether it should pause there.
+func (b *breakpoint) reached(s *Scope) bool {
+	breakpointsMu.Lock()
+	disabled := b.disabled
+	breakpointsMu.Unlock()
+	if disabled || !b.test(s) {
+		return false
+	}
+	breakpointsMu.Lock()
+	defer breakpointsMu.Unlock()
+	b.hits++
+	if b.ignore > 0 {
+		b.ignore--
+		return false
+	}
+	return true
+}
+
+// test reports whether b's condition, if it has one, is true in s.
+func (b *breakpoint) test(s *Scope) bool {
+	if b.cond == nil {
+		return true
+	}
+	v, err := s.eval(b.cond)
+	switch {
+	case err != nil:
+		fmt.Printf("Could not evaluate the condition of the breakpoint %s %s: %v\n", b.preposition(), b.where(), err)
+	case v.Kind() != reflect.Bool:
+		fmt.Printf("The condition of the breakpoint %s %s is a %s, not a bool.\n", b.preposition(), b.where(), typeString(v))
+	default:
+		return v.Bool()
+	}
+	// Pause, so that the user can fix the condition.
+	return true
+}
+
+// allBreakpoints returns every breakpoint, ordered by number. The caller must hold breakpointsMu.
+func allBreakpoints() []*breakpoint {
+	var all []*breakpoint
+	for _, b := range sourceBreakpoints {
+		all = append(all, b)
+	}
+	for _, b := range breakpoints {
+		all = append(all, b)
+	}
+	for _, b := range logpoints {
+		all = append(all, b)
+	}
+	all = append(all, funcBreakpoints...)
+	sort.Sort(byID(all))
+	return all
+}
+
+type byID []*breakpoint
+
+func (s byID) Len() int           { return len(s) }
+func (s byID) Less(i, j int) bool { return s[i].id < s[j].id }
+func (s byID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// removeBreakpoint deletes b. The caller must hold breakpointsMu.
+func removeBreakpoint(b *breakpoint) {
+	defer updateBreakpointCount()
+	switch {
+	case b.source:
+		if sourceBreakpoints[b.loc] == b {
+			delete(sourceBreakpoints, b.loc)
+		}
+	case b.fn != nil:
+		for i, f := range funcBreakpoints {
+			if f == b {
+				funcBreakpoints = append(funcBreakpoints[:i], funcBreakpoints[i+1:]...)
+				break
+			}
+		}
+	case b.message != nil:
+		if logpoints[b.loc] == b {
+			delete(logpoints, b.loc)
+		}
+	default:
+		if breakpoints[b.loc] == b {
+			delete(breakpoints, b.loc)
+		}
+	}
+}
+
+// parseLocation parses a location of the form [[file:]line]. A missing file
+// means the file of scope, and a missing line means the current line. The file
+// may be given by any end of its path that no other instrumented file shares.
+// scope is nil before the program has started, when a file must be given.
+func parseLocation(scope *Scope, line int, loc string) (location, error) {
+	var l location
+	if scope != nil {
+		l = location{file: scope.file, line: line}
+	}
+	if loc == "" && scope != nil {
+		return l, nil
+	}
+	lineStr, name := loc, ""
+	if i := strings.LastIndex(loc, ":"); i >= 0 {
+		name, lineStr = strings.Replace(loc[:i], `\`, "/", -1), loc[i+1:]
+	} else if isFileName(loc) {
+		return l, fmt.Errorf("%s is a file, not a line in it. Locations look like file.go:42.", loc)
+	}
+	n, err := strconv.Atoi(lineStr)
+	if err != nil || n < 1 {
+		return l, fmt.Errorf("%q is not a valid line number. Locations look like file.go:42 or 42.", lineStr)
+	}
+	l.line = n
+	if name == "" && scope == nil {
+		return l, fmt.Errorf("There is no current file before the program starts. Locations look like file.go:42.")
+	}
+	if name != "" {
+		var lines []string
+		if l.file, lines, err = lookupFile(name); err != nil {
+			return l, err
+		}
+		if l.line > len(lines) {
+			return l, fmt.Errorf("%s has only %d lines.", name, len(lines))
+		}
+		return l, nil
+	}
+	if l.line > len(scope.fileText) {
+		return l, fmt.Errorf("%s has only %d lines.", shortName(l.file), len(scope.fileText))
+	}
+	return l, nil
+}
+
+// splitBreakpointArgs splits the arguments of the break and clear commands into
+// the location or function and the rest. A quoted regular expression may contain spaces.
+func splitBreakpointArgs(args string) (where, rest string) {
+	if strings.HasPrefix(args, "'") {
+		if i := strings.Index(args[1:], "'"); i >= 0 {
+			return args[:i+2], strings.TrimSpace(args[i+2:])
+		}
+	}
+	return splitCommand(args)
+}
+
+// isFuncSpec reports whether the argument of a break or clear command names
+// functions rather than a location.
+func isFuncSpec(where string) bool {
+	if strings.HasPrefix(where, "'") {
+		return true
+	}
+	if where == "" || strings.Contains(where, ":") || isFileName(where) {
+		return false
+	}
+	_, err := strconv.Atoi(where)
+	return err != nil
+}
+
+// isFileName reports whether name looks like the name of a source file,
+// or is the name of an instrumented one, rather than a function.
+func isFileName(name string) bool {
+	if strings.HasSuffix(name, ".go") {
+		return true
+	}
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	return len(matchFiles(name)) > 0
+}
+
+// setBreakpoint handles the commands "break [[file:]line | func] [if cond]" and
+// "tbreak", which sets a temporary breakpoint.
+func setBreakpoint(scope *Scope, line int, args string, temporary bool) {
+	where, rest := splitBreakpointArgs(args)
+	if where == "if" {
+		where, rest = "", args
+	}
+	b := &breakpoint{temporary: temporary}
+	err := b.parseWhere(scope, line, where)
+	if err == nil {
+		err = b.parseCondition(rest, "break file.go:42 if x > 3")
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	addBreakpoint(b)
+}
+
+// parseWhere sets the location or functions of b from the argument of a break or log command.
+func (b *breakpoint) parseWhere(scope *Scope, line int, where string) (err error) {
+	if isFuncSpec(where) {
+		b.fn, err = parseFuncPattern(where)
+	} else {
+		b.loc, err = parseLocation(scope, line, where)
+	}
+	return err
+}
+
+// parseCondition sets the condition of b from the text that follows its location,
+// which is either empty or "if cond". example shows how conditions are written.
+func (b *breakpoint) parseCondition(rest, example string) (err error) {
+	if rest == "" {
+		return nil
+	}
+	keyword, condText := splitCommand(rest)
+	if keyword != "if" || condText == "" {
+		return fmt.Errorf(`Conditions follow the location after "if", as in %q.`, example)
+	}
+	if b.cond, err = parser.ParseExpr(condText); err != nil {
+		return fmt.Errorf("Could not parse the condition %q: %v", condText, err)
+	}
+	b.condText = condText
+	return nil
+}
+
+// addBreakpoint numbers b and adds it, replacing any breakpoint of the same kind at the same place.
+func addBreakpoint(b *breakpoint) {
+	breakpointsMu.Lock()
+	defer breakpointsMu.Unlock()
+	defer updateBreakpointCount()
+	var old *breakpoint
+	switch {
+	case b.fn != nil:
+		for _, f := range funcBreakpoints {
+			if f.fn.text == b.fn.text && (f.message == nil) == (b.message == nil) {
+				old = f
+			}
+		}
+	case b.message != nil:
+		old = logpoints[b.loc]
+	default:
+		old = breakpoints[b.loc]
+	}
+	if old != nil {
+		if old.String() == b.String() && old.temporary == b.temporary {
+			fmt.Printf("There is already a %s %s.\n", b.kind(), old.description())
+			return
+		}
+		fmt.Printf("Replacing %s %d %s.\n", b.kind(), old.id, old.description())
+		removeBreakpoint(old)
+	}
+	lastBreakpoint++
+	b.id = lastBreakpoint
+	switch {
+	case b.fn != nil:
+		funcBreakpoints = append(funcBreakpoints, b)
+	case b.message != nil:
+		logpoints[b.loc] = b
+	default:
+		breakpoints[b.loc] = b
+	}
+	kind := "Breakpoint"
+	switch {
+	case b.message != nil:
+		kind = "Logpoint"
+	case b.temporary:
+		kind = "Temporary breakpoint"
+	}
+	fmt.Printf("%s %d set %s.\n", kind, b.id, b.description())
+}
+
+// clearBreakpoints handles the command "clear [[file:]line | func]". It does
+// not affect the breakpoints written in the source or logpoints.
+func clearBreakpoints(scope *Scope, line int, args string) {
+	breakpointsMu.Lock()
+	defer breakpointsMu.Unlock()
+	defer updateBreakpointCount()
+	if args == "" {
+		breakpoints = make(map[location]*breakpoint)
+		var kept []*breakpoint
+		for _, b := range funcBreakpoints {
+			if b.message != nil {
+				kept = append(kept, b)
+			}
+		}
+		funcBreakpoints = kept
+		fmt.Println("Cleared all breakpoints.")
+		return
+	}
+	if isFuncSpec(args) {
+		for _, b := range funcBreakpoints {
+			if b.fn.text == args && b.message == nil {
+				removeBreakpoint(b)
+				fmt.Printf("Cleared breakpoint %d on %s.\n", b.id, args)
+				return
+			}
+		}
+		fmt.Printf("There is no breakpoint on %s.\n", args)
+		return
+	}
+	l, err := parseLocation(scope, line, args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	b := breakpoints[l]
+	if b == nil {
+		fmt.Printf("There is no breakpoint at %s.\n", l)
+		return
+	}
+	removeBreakpoint(b)
+	fmt.Printf("Cleared breakpoint %d at %s.\n", b.id, l)
+}
+
+// listBreakpoints handles the command "info breakpoints".
+func listBreakpoints() {
+	breakpointsMu.Lock()
+	defer breakpointsMu.Unlock()
+	all := allBreakpoints()
+	if len(all) == 0 {
+		fmt.Println("There are no breakpoints.")
+		return
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Num\tWhere\tHits\tNotes")
+	for _, b := range all {
+		where := b.String()
+		if b.source {
+			where += " (in the source)"
+		}
+		var notes []string
+		if b.disabled {
+			notes = append(notes, "disabled")
+		}
+		if b.temporary {
+			notes = append(notes, "temporary")
+		}
+		if b.ignore > 0 {
+			notes = append(notes, fmt.Sprintf("ignoring the next %d hits", b.ignore))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", b.id, where, b.hits, strings.Join(notes, ", "))
+	}
+	w.Flush()
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		// Rows without notes are padded to the width of the column.
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+}
+
+// selectBreakpoints parses a list of breakpoint numbers. An empty list means every breakpoint.
+// The caller must hold breakpointsMu.
+func selectBreakpoints(args string) ([]*breakpoint, error) {
+	all := allBreakpoints()
+	if args == "" {
+		return all, nil
+	}
+	var selected []*breakpoint
+	for _, arg := range strings.Fields(args) {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a breakpoint number. Breakpoint numbers are shown by info breakpoints.", arg)
+		}
+		b := findBreakpoint(all, id)
+		if b == nil {
+			return nil, fmt.Errorf("There is no breakpoint %d.", id)
+		}
+		selected = append(selected, b)
+	}
+	return selected, nil
+}
+
+func findBreakpoint(all []*breakpoint, id int) *breakpoint {
+	for _, b := range all {
+		if b.id == id {
+			return b
+		}
+	}
+	return nil
+}
+
+// changeBreakpoints handles the commands "enable [n...]", "disable [n...]" and
+// "delete [n...]". Without numbers, they apply to every breakpoint.
+func changeBreakpoints(cmd, args string) {
+	breakpointsMu.Lock()
+	defer breakpointsMu.Unlock()
+	bs, err := selectBreakpoints(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	past := map[string]string{"enable": "Enabled", "disable": "Disabled", "delete": "Deleted"}[cmd]
+	if args == "" {
+		if len(bs) == 0 {
+			fmt.Println("There are no breakpoints.")
+			return
+		}
+		fmt.Printf("%s all breakpoints.\n", past)
+	}
+	for _, b := range bs {
+		switch cmd {
+		case "enable":
+			b.disabled = false
+		case "disable":
+			b.disabled = true
+		case "delete":
+			removeBreakpoint(b)
+		}
+		if args != "" {
+			fmt.Printf("%s %s %d %s.\n", past, b.kind(), b.id, b.description())
+		}
+	}
+}
+
+// ignoreBreakpoint handles the command "ignore n count".
+func ignoreBreakpoint(args string) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		fmt.Println(`Give a breakpoint number and a count, as in "ignore 2 5".`)
+		return
+	}
+	count, err := strconv.Atoi(fields[1])
+	if err != nil || count < 0 {
+		fmt.Printf("%q is not a valid count.\n", fields[1])
+		return
+	}
+	breakpointsMu.Lock()
+	defer breakpointsMu.Unlock()
+	bs, err := selectBreakpoints(fields[0])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	b := bs[0]
+	b.ignore = count
+	switch count {
+	case 0:
+		fmt.Printf("Will pause the next time breakpoint %d is reached.\n", b.id)
+	case 1:
+		fmt.Printf("Will ignore the next hit of breakpoint %d.\n", b.id)
+	default:
+		fmt.Printf("Will ignore the next %d hits of breakpoint %d.\n", count, b.id)
+	}
+}
@@ -0,0 +1,97 @@
+package godebug
+
+import (
+	"strings"
+	"testing"
+)
+
+// withFiles replaces the registered files with ones of the given paths,
+// and returns a function that restores them.
+func withFiles(paths ...string) (restore func()) {
+	filesMu.Lock()
+	saved := files
+	files = make(map[string][]string)
+	for _, p := range paths {
+		files[p] = make([]string, 20)
+	}
+	filesMu.Unlock()
+	return func() {
+		filesMu.Lock()
+		files = saved
+		filesMu.Unlock()
+	}
+}
+
+func TestParseLocationSameBaseName(t *testing.T) {
+	defer withFiles("example.com/a/util.go", "example.com/b/util.go", "example.com/b/main.go")()
+	tests := []struct {
+		loc, file, err string
+	}{
+		{loc: "main.go:3", file: "example.com/b/main.go"},
+		{loc: "a/util.go:3", file: "example.com/a/util.go"},
+		{loc: "example.com/b/util.go:3", file: "example.com/b/util.go"},
+		{loc: "util.go:3", err: "util.go is ambiguous. It could be example.com/a/util.go or example.com/b/util.go."},
+		{loc: "c/util.go:3", err: `There is no instrumented file named "c/util.go".`},
+		{loc: "main.go:30", err: "main.go has only 20 lines."},
+	}
+	for _, tt := range tests {
+		l, err := parseLocation(nil, 0, tt.loc)
+		switch {
+		case tt.err != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.err)):
+			t.Errorf("parseLocation(%q): got error %v, want %q", tt.loc, err, tt.err)
+		case tt.err == "" && err != nil:
+			t.Errorf("parseLocation(%q): %v", tt.loc, err)
+		case tt.err == "" && (l.file != tt.file || l.line != 3):
+			t.Errorf("parseLocation(%q) = %s:%d, want %s:3", tt.loc, l.file, l.line, tt.file)
+		}
+	}
+}
+
+func TestParseLocationWithoutScope(t *testing.T) {
+	defer withFiles("main/main.go")()
+	// As for GODEBUG_BREAK=42, before any line of the program has run.
+	if _, err := parseLocation(nil, 0, "42"); err == nil {
+		t.Error("parseLocation without a scope or a file: got no error")
+	}
+}
+
+func TestShortName(t *testing.T) {
+	defer withFiles("example.com/a/util.go", "example.com/b/util.go", "example.com/b/main.go")()
+	for file, want := range map[string]string{
+		"example.com/a/util.go": "a/util.go",
+		"example.com/b/main.go": "main.go",
+	} {
+		if got := shortName(file); got != want {
+			t.Errorf("shortName(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestIsFuncSpec(t *testing.T) {
+	defer withFiles("example.com/worker/worker.go")()
+	for where, want := range map[string]bool{
+		"main.main":        true,
+		"Handle":           true,
+		"'.*Save'":         true,
+		"worker.go":        false,
+		"other.go":         false,
+		"worker/worker.go": false,
+		"worker.go:12":     false,
+		"12":               false,
+		"":                 false,
+	} {
+		if got := isFuncSpec(where); got != want {
+			t.Errorf("isFuncSpec(%q) = %v, want %v", where, got, want)
+		}
+	}
+}
+
+func TestParseLocationFileOnly(t *testing.T) {
+	defer withFiles("example.com/worker/worker.go")()
+	for _, loc := range []string{"worker.go", "other.go"} {
+		_, err := parseLocation(nil, 0, loc)
+		if err == nil || !strings.Contains(err.Error(), "Locations look like file.go:42") {
+			t.Errorf("parseLocation(%q): got error %v, want one showing what locations look like", loc, err)
+		}
+	}
+}
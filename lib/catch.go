@@ -0,0 +1,414 @@
+package godebug
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// When panics are caught, the debugger pauses in the innermost instrumented
+// function that a panic passes through, at the last line that function ran.
+// The panic continues once the program resumes. Catching is on by default,
+// and is turned off by -catchpanic=false or from the prompt.
+var catchPanics int32 = catchPanicOn
+
+const (
+	catchPanicOff = iota
+	catchPanicOn
+
+	// catchPanicRecovered is like catchPanicOn, but recovered
+	// panics are also reported by functions that call recover.
+	catchPanicRecovered
+)
+
+// catchPanicEnvVar is set to false by the godebug command when it is given -catchpanic=false.
+const catchPanicEnvVar = "GODEBUG_CATCH_PANIC"
+
+func init() {
+	if v, err := strconv.ParseBool(os.Getenv(catchPanicEnvVar)); err == nil && !v {
+		catchPanics = catchPanicOff
+	}
+}
+
+// watchingPanics reports whether ExitFunc needs to know if the function ctx belongs
+// to is panicking, which takes a walk up the stack unless Returning was called.
+func (ctx *Context) watchingPanics() bool {
+	return atomic.LoadInt32(&catchPanics) != catchPanicOff || atomic.LoadInt32(&catchErrors) != 0 ||
+		currentState == finish && ctx.frame == finishing
+}
+
+// catching reports whether ExitFunc should pause for a panic in flight. Once a
+// panic has been caught, it is not caught again in the frames it unwinds
+// through afterwards.
+func (g *goroutine) catching(panicked bool) bool {
+	if !panicked {
+		g.caughtPanic = false
+		return false
+	}
+	return atomic.LoadInt32(&catchPanics) != catchPanicOff && !g.caughtPanic
+}
+
+// panicking reports whether ExitFunc is being run because of a panic rather than
+// a return. It must be called from ExitFunc. The runtime calls deferred functions
+// from its panic implementation while a panic is unwinding the stack, and from
+// the function itself or from deferreturn otherwise.
+func panicking() bool {
+	pcs := make([]uintptr, 8)
+	// Skip runtime.Callers, panicking and ExitFunc.
+	n := runtime.Callers(3, pcs)
+	for _, pc := range pcs[:n] {
+		f := runtime.FuncForPC(pc - 1)
+		if f == nil {
+			return false
+		}
+		switch name := f.Name(); {
+		case name == "runtime.gopanic":
+			return true
+		case !strings.HasPrefix(name, "runtime."):
+			return false
+		}
+	}
+	return false
+}
+
+// caughtPanic is called by ExitFunc when the function ctx belongs to is panicking.
+// It pauses at the last line the function ran. The panic goes on afterwards.
+func caughtPanic(ctx *Context) {
+	ctx.g.caughtPanic = true
+	f := ctx.frame
+	if atomic.CompareAndSwapInt32(&currentState, run, step) {
+		atomic.StoreUint32(&currentGoroutine, ctx.goroutine)
+	} else if following := atomic.LoadUint32(&currentGoroutine); following != ctx.goroutine {
+		fmt.Printf("Goroutine %d panicked in %s while goroutine %d was being debugged.\n", ctx.goroutine, f.fn, following)
+		return
+	}
+	fmt.Printf("%s panicked.\n", f.fn)
+	if f.scope == nil {
+		// No line in the function has run, so there is nowhere to pause.
+		return
+	}
+	debuggerDepth = currentDepth
+	justLeft = false
+	ctx.g.at(f, f.scope, f.exitLine())
+	fmt.Println("-> " + strings.TrimSpace(f.scope.fileText[f.line-1]))
+	waitForInput(ctx, f.scope, f.line)
+}
+
+// recovered is called by EnterFuncWithRecovers with each value that the function
+// called name gets from recover. Panics it stops are reported if the user asked for that.
+func recovered(name string, r interface{}) {
+	if r != nil && atomic.LoadInt32(&catchPanics) == catchPanicRecovered {
+		fmt.Printf("%s recovered from a panic: %s\n", name, formatPanic(r))
+	}
+}
+
+func formatPanic(r interface{}) string {
+	if err, ok := r.(error); ok {
+		return err.Error()
+	}
+	return formatValue(value{Value: reflect.ValueOf(r)})
+}
+
+// When errors are caught, the debugger pauses when an instrumented function whose last
+// result has static type error returns a non-nil one. The packages in errorFilter limit
+// which functions are caught.
+var (
+	catchErrors int32
+
+	errorFilterMu sync.Mutex
+	errorFilter   packageFilter
+)
+
+// returned is called by ExitFunc with a pointer to the last result of the function
+// ctx belongs to, when it is not panicking.
+func returned(ctx *Context, result interface{}) {
+	if atomic.LoadInt32(&catchErrors) == 0 {
+		return
+	}
+	p, ok := result.(*error)
+	if !ok || *p == nil {
+		return
+	}
+	err, f := *p, ctx.frame
+	errorFilterMu.Lock()
+	match := errorFilter.match(f.fn)
+	errorFilterMu.Unlock()
+	if !match || f.scope == nil {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&currentState, run, step) {
+		atomic.StoreUint32(&currentGoroutine, ctx.goroutine)
+	} else if following := atomic.LoadUint32(&currentGoroutine); following != ctx.goroutine {
+		addMissed(fmt.Sprintf("Goroutine %d: %s returned an error while goroutine %d was being debugged: %v", ctx.goroutine, f.fn, following, err))
+		return
+	}
+	fmt.Printf("%s returned an error: %v\n", f.fn, err)
+	debuggerDepth = currentDepth
+	justLeft = false
+	ctx.g.at(f, f.scope, f.exitLine())
+	fmt.Println("-> " + strings.TrimSpace(f.scope.fileText[f.line-1]))
+	waitForInput(ctx, f.scope, f.line)
+}
+
+// A packageFilter selects functions by the package they are declared in.
+// Functions in a package in exclude never match. If include is not empty,
+// only functions in one of its packages match.
+type packageFilter struct {
+	include, exclude []string
+}
+
+func (pf packageFilter) match(fn string) bool {
+	pkg := funcPackage(fn)
+	for _, p := range pf.exclude {
+		if matchPackage(pkg, p) {
+			return false
+		}
+	}
+	if len(pf.include) == 0 {
+		return true
+	}
+	for _, p := range pf.include {
+		if matchPackage(pkg, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (pf packageFilter) String() string {
+	var s string
+	if len(pf.include) > 0 {
+		s += " Only errors from functions in " + strings.Join(pf.include, ", ") + " are caught."
+	}
+	if len(pf.exclude) > 0 {
+		s += " Errors from functions in " + strings.Join(pf.exclude, ", ") + " are not caught."
+	}
+	return s
+}
+
+// funcPackage returns the import path of the package that the function
+// with the qualified name fn is declared in.
+func funcPackage(fn string) string {
+	i := strings.LastIndex(fn, "/") + 1
+	if j := strings.Index(fn[i:], "."); j >= 0 {
+		return fn[:i+j]
+	}
+	return fn
+}
+
+// matchPackage reports whether the package with import path pkg is the one
+// called name. name may leave off the start of the path, as in "store" for
+// "github.com/user/app/store".
+func matchPackage(pkg, name string) bool {
+	return pkg == name || strings.HasSuffix(pkg, "/"+name)
+}
+
+// When exits are caught, the debugger pauses before os.Exit, log.Fatal, log.Fatalf,
+// log.Fatalln or the log.Logger methods of the same names end the program. The
+// generator routes calls to them in instrumented code through Exit, Fatal and Fatalf.
+var catchExits int32
+
+// attached is set once the debugger has paused, after which the user is told
+// when the program exits.
+var attached int32
+
+// Exit is called by generated code in place of os.Exit, which is passed as exit.
+func Exit(c *Context, exit func(int), code int) {
+	exiting(c, code)
+	exit(code)
+}
+
+// Fatal is called by generated code in place of log.Fatal, log.Fatalln,
+// or the log.Logger methods with those names, which are passed as fatal.
+func Fatal(c *Context, fatal func(...interface{}), v ...interface{}) {
+	exiting(c, 1)
+	fatal(v...)
+}
+
+// Fatalf is called by generated code in place of log.Fatalf or
+// log.Logger's Fatalf method, which is passed as fatalf.
+func Fatalf(c *Context, fatalf func(string, ...interface{}), format string, v ...interface{}) {
+	exiting(c, 1)
+	fatalf(format, v...)
+}
+
+// exiting is called just before the program exits with code from the function c belongs to.
+// It pauses at the line that is ending the program if exits are caught.
+func exiting(c *Context, code int) {
+	f := c.frame
+	if atomic.LoadInt32(&catchExits) != 0 && f.scope != nil {
+		if atomic.CompareAndSwapInt32(&currentState, run, step) {
+			atomic.StoreUint32(&currentGoroutine, c.goroutine)
+		}
+		if atomic.LoadUint32(&currentGoroutine) == c.goroutine {
+			fmt.Printf("%s is ending the program.\n", f.fn)
+			debuggerDepth = currentDepth
+			justLeft = false
+			fmt.Println("-> " + strings.TrimSpace(f.scope.fileText[f.line-1]))
+			waitForInput(c, f.scope, f.line)
+		}
+	}
+	if atomic.LoadInt32(&attached) != 0 {
+		fmt.Printf("Program exiting with code %d.\n", code)
+	}
+}
+
+// breakOnFail is set by the -break-on-fail flag of godebug test or the command
+// "catch fail". The debugger then pauses at statements that fail a test, like
+// t.Errorf(...), before they run.
+var breakOnFail int32
+
+// breakOnFailEnvVar is set by the godebug command when it is given -break-on-fail.
+const breakOnFailEnvVar = "GODEBUG_BREAK_ON_FAIL"
+
+func init() {
+	if v, err := strconv.ParseBool(os.Getenv(breakOnFailEnvVar)); err == nil && v {
+		breakOnFail = 1
+	}
+}
+
+// TestFailure is the generated marker for a statement that calls a method of
+// testing.T or testing.B that fails the test. It tells the next line about it.
+func TestFailure(ctx *Context) {
+	ctx.atFailure = true
+}
+
+// hitTestFailure reports whether the debugger should pause because the line c is
+// at is about to fail a test. It must be called after the line is recorded in c's frame.
+func hitTestFailure(c *Context) bool {
+	if !c.atFailure {
+		return false
+	}
+	c.atFailure = false
+	if atomic.LoadInt32(&breakOnFail) == 0 {
+		return false
+	}
+	f := c.frame
+	if atomic.CompareAndSwapInt32(&currentState, run, step) {
+		atomic.StoreUint32(&currentGoroutine, c.goroutine)
+	} else if following := atomic.LoadUint32(&currentGoroutine); following != c.goroutine {
+		addMissed(fmt.Sprintf("Goroutine %d failed the test at %s while goroutine %d was being debugged.", c.goroutine, location{f.scope.file, f.line}, following))
+		return false
+	}
+	fmt.Printf("%s is failing the test.\n", f.fn)
+	return true
+}
+
+// catch handles the commands "catch", "catch panic [on|off|recovered]",
+// "catch error [off | [-]pkg...]", "catch exit [on|off]" and "catch fail [on|off]".
+func catch(args string) {
+	kind, mode := splitCommand(args)
+	switch kind {
+	case "":
+		describeCatchPanics()
+		describeCatchErrors()
+		describeCatchExits()
+		describeCatchFailures()
+		return
+	case "error":
+		catchErrorsCommand(mode)
+		return
+	case "exit":
+		switch mode {
+		case "", "on":
+			atomic.StoreInt32(&catchExits, 1)
+		case "off":
+			atomic.StoreInt32(&catchExits, 0)
+		default:
+			fmt.Printf(`Expected "catch exit" or "catch exit off", but got %q.`+"\n", args)
+			return
+		}
+		describeCatchExits()
+		return
+	case "fail":
+		switch mode {
+		case "", "on":
+			atomic.StoreInt32(&breakOnFail, 1)
+		case "off":
+			atomic.StoreInt32(&breakOnFail, 0)
+		default:
+			fmt.Printf(`Expected "catch fail" or "catch fail off", but got %q.`+"\n", args)
+			return
+		}
+		describeCatchFailures()
+		return
+	case "panic":
+		switch mode {
+		case "", "on":
+			atomic.StoreInt32(&catchPanics, catchPanicOn)
+		case "off":
+			atomic.StoreInt32(&catchPanics, catchPanicOff)
+		case "recovered":
+			atomic.StoreInt32(&catchPanics, catchPanicRecovered)
+		default:
+			fmt.Printf(`Expected "catch panic", "catch panic off" or "catch panic recovered", but got %q.`+"\n", args)
+			return
+		}
+		describeCatchPanics()
+		return
+	}
+	fmt.Printf("Cannot catch %q. Panics, errors, exits and test failures can be caught.\n", kind)
+}
+
+func catchErrorsCommand(args string) {
+	if args == "off" {
+		atomic.StoreInt32(&catchErrors, 0)
+		describeCatchErrors()
+		return
+	}
+	var pf packageFilter
+	for _, name := range strings.Fields(args) {
+		if strings.HasPrefix(name, "-") {
+			pf.exclude = append(pf.exclude, name[1:])
+		} else {
+			pf.include = append(pf.include, name)
+		}
+	}
+	errorFilterMu.Lock()
+	errorFilter = pf
+	errorFilterMu.Unlock()
+	atomic.StoreInt32(&catchErrors, 1)
+	describeCatchErrors()
+}
+
+func describeCatchErrors() {
+	if atomic.LoadInt32(&catchErrors) == 0 {
+		fmt.Println("Errors are not caught.")
+		return
+	}
+	errorFilterMu.Lock()
+	defer errorFilterMu.Unlock()
+	fmt.Println("Errors are caught. The debugger will pause when an instrumented function returns a non-nil error." + errorFilter.String())
+}
+
+func describeCatchPanics() {
+	switch atomic.LoadInt32(&catchPanics) {
+	case catchPanicOff:
+		fmt.Println("Panics are not caught.")
+	case catchPanicOn:
+		fmt.Println("Panics are caught. The debugger will pause in the innermost instrumented function a panic passes through.")
+	case catchPanicRecovered:
+		fmt.Println("Panics are caught. The debugger will pause in the innermost instrumented function a panic passes through, and report panics that are recovered.")
+	}
+}
+
+func describeCatchExits() {
+	if atomic.LoadInt32(&catchExits) == 0 {
+		fmt.Println("Exits are not caught.")
+		return
+	}
+	fmt.Println("Exits are caught. The debugger will pause before os.Exit or log.Fatal ends the program.")
+}
+
+func describeCatchFailures() {
+	if atomic.LoadInt32(&breakOnFail) == 0 {
+		fmt.Println("Test failures are not caught.")
+		return
+	}
+	fmt.Println("Test failures are caught. The debugger will pause before a test calls Error, Errorf, Fatal, Fatalf or Fail.")
+}
@@ -0,0 +1,104 @@
+package godebug
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// stepped records whether the program last resumed with next or step, after
+// which the debugger shows the variables that changed when it pauses again.
+var stepped bool
+
+// A varKey identifies a variable from one pause to the next by its name and
+// how deeply nested the scope that declares it is. The scopes of a block are
+// new each time the block runs, so a variable declared in the body of a loop
+// would otherwise be a different one on every iteration, while a variable
+// that shadows another of the same name is told apart from it.
+type varKey struct {
+	name  string
+	depth int
+}
+
+// eachVisibleVar calls fn with every variable in s or its ancestors that
+// is not shadowed, in the order locals prints them.
+func eachVisibleVar(s *Scope, fn func(k varKey, v value)) {
+	for depth, scope := range s.chain() {
+		for _, name := range scope.names {
+			if _, ok := scope.vars[name]; !ok || s.shadows(scope, name) {
+				continue
+			}
+			v, _ := scope.binding(name)
+			fn(varKey{name, depth}, v)
+		}
+	}
+}
+
+// maxSnapshotSize is how many elements, fields and map entries of a variable
+// snapshotVars copies. A variable that has more is too large to compare from
+// one pause to the next.
+const maxSnapshotSize = 10000
+
+// snapshotVars returns a snapshot of every variable visible in s.
+// Like a watchpoint, it copies pointers but not what they point to.
+// A variable too large to copy has the invalid value.
+func snapshotVars(s *Scope) map[varKey]reflect.Value {
+	values := make(map[varKey]reflect.Value)
+	eachVisibleVar(s, func(k varKey, v value) {
+		c, ok := snapshotAtMost(v.Value, maxSnapshotSize)
+		if !ok {
+			c = reflect.Value{}
+		}
+		values[k] = c
+	})
+	return values
+}
+
+// printChanges prints the variables visible in s whose values differ from
+// their snapshot in old, and returns how many it printed. Variables that
+// are not in old, because they were declared since, are left out, as are
+// the ones too large to compare unless tooLarge is set.
+func printChanges(s *Scope, old map[varKey]reflect.Value, tooLarge bool) int {
+	n := 0
+	eachVisibleVar(s, func(k varKey, v value) {
+		before, ok := old[k]
+		switch {
+		case !ok:
+			return
+		case !before.IsValid():
+			if tooLarge {
+				fmt.Printf("%s (too large to compare)\n", k.name)
+				n++
+			}
+			return
+		case before.Type() != v.Type() || sameValue(before, v.Value):
+			// A variable of another type is one declared in another block.
+			return
+		}
+		fmt.Printf("%s = %s (was %s)\n", k.name, formatValue(v), formatValue(value{Value: before}))
+		n++
+	})
+	return n
+}
+
+// pausedFrame returns the frame in v that the debugger paused at s in, or nil if there is none.
+func (v *stackView) pausedFrame(s *Scope) *frame {
+	for _, f := range v.frames {
+		if f.scope == s {
+			return f.frame
+		}
+	}
+	return nil
+}
+
+// diff handles the command "diff". It shows what changed in the selected
+// frame since the debugger last paused in it.
+func (v *stackView) diff() {
+	f := v.frames[v.selected]
+	if f.paused == nil {
+		fmt.Printf("This is the first pause in %s, so there is nothing to compare.\n", f.fn)
+		return
+	}
+	if printChanges(f.scope, f.paused, true) == 0 {
+		fmt.Println("No variables have changed since the last pause.")
+	}
+}
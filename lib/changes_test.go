@@ -0,0 +1,40 @@
+package godebug
+
+import "testing"
+
+// newTestScope returns the outermost scope of a function, in a file-level scope.
+func newTestScope() *Scope {
+	return &Scope{
+		vars:   make(map[string]interface{}),
+		consts: make(map[string]interface{}),
+		types:  make(map[string]string),
+		parent: &Scope{},
+	}
+}
+
+func TestSnapshotVars(t *testing.T) {
+	self := map[string]interface{}{"n": 1}
+	self["self"] = self
+	big := make([]int, maxSnapshotSize+1)
+	n := 1
+	s := newTestScope()
+	s.Declare("self", "map[string]interface{}", &self, "big", "[]int", &big, "n", "int", &n)
+
+	old := snapshotVars(s)
+	if v := old[varKey{"big", 0}]; v.IsValid() {
+		t.Errorf("snapshot of a slice of %d ints: got %v, want none", len(big), v)
+	}
+	if got := printChanges(s, old, true); got != 1 {
+		t.Errorf("printChanges before anything changed: printed %d, want 1 for big", got)
+	}
+
+	self["n"] = 2
+	big[0] = 1
+	n = 2
+	if got := printChanges(s, old, false); got != 2 {
+		t.Errorf("printChanges after self and n changed: printed %d, want 2", got)
+	}
+	if got := printChanges(s, old, true); got != 3 {
+		t.Errorf("printChanges after self and n changed, with big: printed %d, want 3", got)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"reflect"
 	"strings"
 	"sync/atomic"
 	"unicode"
@@ -15,16 +14,35 @@ type Scope struct {
 	vars, consts map[string]interface{}
 	parent       *Scope
 	fileText     []string
+	file         string
+
+	// types holds the declared type of each variable and constant in s,
+	// as written in its package, or "" if it is not known.
+	types map[string]string
+
+	// names lists the variables and constants in s in the order they were declared.
+	names []string
+
+	// args lists the function arguments declared in s. It is not nil
+	// if and only if s is the outermost scope of a function.
+	args []string
 }
 
 // EnteringNewScope returns a new Scope and internally sets
-// the current scope to be the returned scope.
-func EnteringNewScope(fileText string) *Scope {
-	return &Scope{
+// the current scope to be the returned scope. file is the
+// base name of the source file whose contents are fileText,
+// and breakpointLines are the lines of the breakpoints in it.
+func EnteringNewScope(fileText, file string, breakpointLines ...int) *Scope {
+	s := &Scope{
 		vars:     make(map[string]interface{}),
 		consts:   make(map[string]interface{}),
+		types:    make(map[string]string),
 		fileText: parseLines(fileText),
+		file:     file,
 	}
+	registerFile(s)
+	registerSourceBreakpoints(s, breakpointLines)
+	return s
 }
 
 func parseLines(text string) []string {
@@ -45,47 +63,60 @@ func (s *Scope) EnteringNewChildScope() *Scope {
 	return &Scope{
 		vars:     make(map[string]interface{}),
 		consts:   make(map[string]interface{}),
+		types:    make(map[string]string),
 		parent:   s,
 		fileText: s.fileText,
+		file:     s.file,
 	}
 }
 
-func (s *Scope) getIdent(name string) (i interface{}, ok bool) {
-	// TODO: This can race with other goroutines setting the value you are printing.
-	for scope := s; scope != nil; scope = scope.parent {
-		if i, ok = scope.vars[name]; ok {
-			return dereference(i), true
-		}
-		if i, ok = scope.consts[name]; ok {
-			return i, true
-		}
-	}
-	return nil, false
+// Declare creates new variable bindings in s from a list of name, type, value
+// triples, where the type is the declared type of the variable as a string. The
+// values should be pointers to the values in the program rather than copies of
+// them so that s can track changes to them.
+func (s *Scope) Declare(nametypevalue ...interface{}) {
+	s.addIdents(s.vars, "Declare", nametypevalue...)
 }
 
-// Declare creates new variable bindings in s from a list of name, value pairs.
-// The values should be pointers to the values in the program rather than copies
-// of them so that s can track changes to them.
-func (s *Scope) Declare(namevalue ...interface{}) {
-	s.addIdents(s.vars, "Declare", namevalue...)
+// DeclareArgs is like Declare, but for the receiver and parameters of a function.
+// It must be called on the function's outermost scope, even if there are none.
+func (s *Scope) DeclareArgs(nametypevalue ...interface{}) {
+	if s.args == nil {
+		s.args = make([]string, 0, len(nametypevalue)/3)
+	}
+	for i := 0; i < len(nametypevalue); i += 3 {
+		if name, ok := nametypevalue[i].(string); ok {
+			s.args = append(s.args, name)
+		}
+	}
+	s.addIdents(s.vars, "DeclareArgs", nametypevalue...)
 }
 
 // Constant is like Declare, but for constants. The values must be passed directly.
-func (s *Scope) Constant(namevalue ...interface{}) {
-	s.addIdents(s.consts, "Constant", namevalue...)
+func (s *Scope) Constant(nametypevalue ...interface{}) {
+	s.addIdents(s.consts, "Constant", nametypevalue...)
 }
 
-func (s *Scope) addIdents(to map[string]interface{}, funcName string, namevalue ...interface{}) {
+func (s *Scope) addIdents(to map[string]interface{}, funcName string, nametypevalue ...interface{}) {
 	var i int
-	for i = 0; i+1 < len(namevalue); i += 2 {
-		name, ok := namevalue[i].(string)
+	for i = 0; i+2 < len(nametypevalue); i += 3 {
+		name, ok := nametypevalue[i].(string)
+		if !ok {
+			panic(fmt.Sprintf("programming error: got a name argument to %s that was not a string", funcName))
+		}
+		typ, ok := nametypevalue[i+1].(string)
 		if !ok {
-			panic(fmt.Sprintf("programming error: got odd-numbered argument to %s that was not a string", funcName))
+			panic(fmt.Sprintf("programming error: got a type argument to %s that was not a string", funcName))
 		}
-		to[name] = namevalue[i+1]
+		if _, ok := to[name]; !ok {
+			// Loops declare their variables again on every iteration.
+			s.names = append(s.names, name)
+		}
+		to[name] = nametypevalue[i+2]
+		s.types[name] = typ
 	}
-	if i != len(namevalue) {
-		panic(fmt.Sprintf("programming error: called %s with odd number of arguments", funcName))
+	if i != len(nametypevalue) {
+		panic(fmt.Sprintf("programming error: called %s with a number of arguments that is not a multiple of three", funcName))
 	}
 }
 
@@ -93,6 +124,7 @@ const (
 	run int32 = iota
 	next
 	step
+	finish // run until the frame in finishing returns
 )
 
 type contextManager interface {
@@ -116,8 +148,9 @@ var (
 // EnterFunc marks the beginning of a function. Calling fn should be equivalent to running
 // the function that is being entered. If proceed is false, EnterFunc did in fact call
 // fn, and so the caller of EnterFunc should return immediately rather than proceed to
-// duplicate the effects of fn.
-func EnterFunc(fn func()) (ctx *Context, proceed bool) {
+// duplicate the effects of fn. name is the qualified name of the function and file is
+// the base name of the file it is declared in.
+func EnterFunc(name, file string, fn func()) (ctx *Context, proceed bool) {
 	// We've entered a new function. If we're in step or next mode we have some bookkeeping to do,
 	// but only if the current goroutine is the one the debugger is following.
 	//
@@ -137,42 +170,41 @@ func EnterFunc(fn func()) (ctx *Context, proceed bool) {
 		// invoke fn, which means the caller should not proceed. After running it, return false.
 		id := uint32(ids.Acquire())
 		defer ids.Release(uint(id))
-		context.SetValues(fn, goroutineKey, id)
+		g := newGoroutine(id)
+		defer g.exit()
+		context.SetValues(fn, goroutineKey, g)
 		return nil, false
 	}
-	if val.(uint32) == atomic.LoadUint32(&currentGoroutine) && currentState != run {
-		if justLeft {
-			// This means this goroutine ran ExitFunc followed by EnterFunc with no intervening debug calls,
-			// probably because the parent caller is in another package which has not been instrumented.
-			debuggerDepth++
-			justLeft = false
-		}
-		currentDepth++
-	}
-	return &Context{goroutine: val.(uint32)}, true
+	return enterFunc(val.(*goroutine), name, file), true
 }
 
 // EnterFuncLit is like EnterFunc, but intended for function literals. The passed callback takes a *Context rather than no input.
-func EnterFuncLit(fn func(*Context)) (ctx *Context, proceed bool) {
+func EnterFuncLit(name, file string, fn func(*Context)) (ctx *Context, proceed bool) {
 	val, ok := context.GetValue(goroutineKey)
 	if !ok {
 		id := uint32(ids.Acquire())
 		defer ids.Release(uint(id))
+		g := newGoroutine(id)
+		defer g.exit()
 		context.SetValues(func() {
-			fn(&Context{goroutine: id})
-		}, goroutineKey, id)
+			fn(&Context{goroutine: id, g: g, frame: g.push(name, file)})
+		}, goroutineKey, g)
 		return nil, false
 	}
-	if val.(uint32) == atomic.LoadUint32(&currentGoroutine) && currentState != run {
+	return enterFunc(val.(*goroutine), name, file), true
+}
+
+func enterFunc(g *goroutine, name, file string) *Context {
+	if g.id == atomic.LoadUint32(&currentGoroutine) && currentState != run {
 		if justLeft {
-			// This means this goroutine ran ExitFunc followed by EnterFuncLit with no intervening debug calls,
+			// This means this goroutine ran ExitFunc followed by EnterFunc with no intervening debug calls,
 			// probably because the parent caller is in another package which has not been instrumented.
 			debuggerDepth++
 			justLeft = false
 		}
 		currentDepth++
 	}
-	return &Context{goroutine: val.(uint32)}, true
+	return &Context{goroutine: g.id, g: g, frame: g.push(name, file)}
 }
 
 // EnterFuncWithRecovers is a special wrapper for functions that call recover().
@@ -188,7 +220,9 @@ func EnterFuncLit(fn func(*Context)) (ctx *Context, proceed bool) {
 //
 // EnterFuncWithRecovers takes care of maintaining goroutine-local-storage in the new
 // goroutine, as well as propagating any panic from that goroutine to the original goroutine.
-func EnterFuncWithRecovers(r chan chan interface{}, fn func(*Context)) (<-chan chan interface{}, chan interface{}) {
+//
+// results are pointers to the function's results, as for ExitFunc.
+func EnterFuncWithRecovers(name, file string, r chan chan interface{}, fn func(*Context), results ...interface{}) (<-chan chan interface{}, chan interface{}) {
 	var (
 		quit      = make(chan struct{})
 		recovers  = make(chan chan interface{})
@@ -203,7 +237,13 @@ func EnterFuncWithRecovers(r chan chan interface{}, fn func(*Context)) (<-chan c
 			case <-quit:
 				return
 			case r <- rr: // send the read end to the embedded function
-				recovers <- rr // send the write end to the calling function
+				// Send a write end to the calling function, and pass on what it
+				// recovers, so that recovered panics can be reported.
+				w := make(chan interface{})
+				recovers <- w
+				v := <-w
+				recovered(name, v)
+				rr <- v
 			}
 		}
 	}()
@@ -217,17 +257,35 @@ func EnterFuncWithRecovers(r chan chan interface{}, fn func(*Context)) (<-chan c
 			}
 			close(panicChan)
 		}()
-		if ctx, ok = EnterFuncLit(fn); ok {
-			defer ExitFunc(ctx)
+		if ctx, ok = EnterFuncLit(name, file, fn); ok {
+			defer ExitFunc(ctx, results...)
 			fn(ctx)
+			Returning(ctx)
 		}
 		didPanic = false
 	})
 	return recovers, panicChan
 }
 
-// ExitFunc marks the end of a function.
-func ExitFunc(ctx *Context) {
+// ExitFunc marks the end of a function. results are pointers to the function's
+// results, which hold the values it is returning by the time ExitFunc is called.
+//
+// If the function is panicking, ExitFunc pauses at the last line it ran,
+// with its scope intact. The panic is not recovered, so it goes on when the
+// program resumes as if the function were not instrumented.
+// It also pauses there if errors are caught and the function returns one.
+func ExitFunc(ctx *Context, results ...interface{}) {
+	panicked := false
+	if ctx.g.evaluating == 0 && !ctx.returning && ctx.watchingPanics() {
+		panicked = panicking()
+		if ctx.g.catching(panicked) {
+			caughtPanic(ctx)
+		}
+	}
+	if !panicked && len(results) > 0 && ctx.g.evaluating == 0 {
+		returned(ctx, results[len(results)-1])
+	}
+	ctx.g.pop(ctx.frame)
 	if atomic.LoadUint32(&currentGoroutine) != ctx.goroutine {
 		return
 	}
@@ -239,11 +297,32 @@ func ExitFunc(ctx *Context) {
 		justLeft = true
 	}
 	currentDepth--
+	if currentState == finish && ctx.frame == finishing {
+		finished(ctx, results, panicked)
+	}
 }
 
 // Context contains debugging context information.
 type Context struct {
 	goroutine uint32
+	g         *goroutine
+	frame     *frame
+
+	// atBreakpoint is set by SetTraceGen. It tells the next line
+	// that it is the location of a breakpoint written in the source.
+	atBreakpoint bool
+
+	// atFailure is set by TestFailure. It tells the next line
+	// that it fails a test.
+	atFailure bool
+
+	// logMessage is set by Logpoint. It is the message of a
+	// logpoint written in the source at the next line.
+	logMessage string
+
+	// returning is set by Returning. It tells ExitFunc that the
+	// function is not panicking, so it need not look at the stack.
+	returning bool
 }
 
 type caseSentinel int
@@ -290,18 +369,32 @@ func Line(c *Context, s *Scope, line int) {
 }
 
 func shouldPause(c *Context) bool {
-	return atomic.LoadUint32(&currentGoroutine) == c.goroutine &&
+	return c.g.evaluating == 0 && atomic.LoadUint32(&currentGoroutine) == c.goroutine &&
 		(currentState == step || (currentState == next && currentDepth == debuggerDepth))
 }
 
 func lineWithPrefix(c *Context, s *Scope, line int, prefix string) {
-	if !shouldPause(c) {
+	if c.g.evaluating > 0 {
+		c.g.at(c.frame, s, line)
+		return
+	}
+	waitForWorld(c)
+	watched := hitWatchpoint(c)
+	first := c.frame.scope == nil
+	c.g.at(c.frame, s, line)
+	source := c.atBreakpoint
+	c.atBreakpoint = false
+	logSource(c, s, line)
+	// Check breakpoints even if the debugger is pausing anyway, so that hits are counted.
+	hit := hitBreakpoint(c, s, line, first, source)
+	failing := hitTestFailure(c)
+	if !hit && !watched && !failing && !hitInterrupt(c) && !shouldPause(c) {
 		return
 	}
 	debuggerDepth = currentDepth
 	justLeft = false
 	fmt.Println("-> " + prefix + strings.TrimSpace(s.fileText[line-1])) // token.Position.Line starts at 1.
-	waitForInput(s, line)
+	waitForInput(c, s, line)
 }
 
 var skipNextElseIfExpr bool
@@ -329,21 +422,31 @@ func ElseIfExpr(c *Context, s *Scope, line int) {
 // Defer marks a defer statement. Intended to be run in a defer statement of its own
 // after the corresponding defer in the original source.
 func Defer(c *Context, s *Scope, line int) {
+	// The deferred functions may panic after a return statement was marked.
+	c.returning = false
+	if c.frame.returnLine == 0 {
+		c.frame.returnLine = c.frame.line
+	}
 	lineWithPrefix(c, s, line, "<Running deferred function>: ")
 }
 
+// Returning marks that the function ctx belongs to is returning rather than
+// panicking. The generated code calls it before return statements whose results
+// cannot panic, and after the body of a function literal has run.
+func Returning(ctx *Context) {
+	ctx.returning = true
+}
+
 // SetTrace is deprecated. It will be deleted in a future release.
 func SetTrace() {
 }
 
-// SetTraceGen is the generated entrypoint to the debugger.
+// SetTraceGen is the generated entrypoint to the debugger. It marks the line
+// that follows as the location of a breakpoint written in the source. Whether
+// the debugger pauses there is decided by Line, since the breakpoint may have
+// been disabled from the prompt.
 func SetTraceGen(ctx *Context) {
-	// TODO: The case where the user calls SetTrace multiple times has not been thought out at all yet.
-	if atomic.LoadInt32(&currentState) != run {
-		return
-	}
-	atomic.StoreUint32(&currentGoroutine, ctx.goroutine)
-	currentState = step
+	ctx.atBreakpoint = true
 }
 
 var help = `
@@ -351,19 +454,89 @@ Commands:
     (h) help: Print this help.
     (n) next: Run the next line.
     (s) step: Run for one step.
-    (c) continue: Run until the next breakpoint.
+    (c) continue: Run until the next breakpoint. Press Ctrl-C to pause again.
+            Programs that handle Ctrl-C themselves receive it as well.
     (l) list: Show the current line in context of the code around it.
-    (p) print <var>: Print a variable.
+    (p) print <expr>: Print the value of a Go expression. Expressions may use
+            variables in scope, field selectors, indexing, slicing, pointer
+            dereference, type assertions, len, cap and arithmetic.
+        p/<f> <expr>: Print with the integers in the value in hex (x), binary (b),
+            decimal (d) or as characters (c), byte and rune slices as strings (s),
+            or the whole value as Go syntax, as %#v prints it (raw).
+        x/<n> <expr>: Show the first n bytes of a []byte, byte array or string as a hex dump.
+        set <var> = <expr>: Change the value of a variable.
+        set print [depth|elements|strings] [n]: Show or change how many levels of nested
+            values, elements of each slice, array or map, or bytes of each string
+            print shows. 0 means no limit.
+        whatis <expr>: Show the declared type of an expression, and for an interface
+            the type of the value it holds.
+        ptype <type>: Show the fields and methods of a type declared in an instrumented package.
+        locals: Print the variables in scope.
+        args: Print the arguments of the current function.
+        info scopes: Print the variables in each enclosing scope.
+        finish: Run until the selected function returns, and show its results.
+        bt: Show the stack of instrumented functions.
+        up, down, frame <n>: Select the caller's, callee's or nth frame.
+            print, set, list and break then work in that frame.
+        diff: Show the variables that changed since the debugger last paused in the
+            selected function. After next and step, they are shown automatically.
+        watch <expr>: Pause whenever the value of an expression changes.
+        unwatch [n]: Delete watchpoint n, or all watchpoints.
+        display [expr]: Print the value of an expression every time the debugger pauses
+            where it can be evaluated. Without an expression, print them all now.
+        undisplay [n]: Stop displaying expression n, or all of them.
+        info display: List the display expressions.
+        break [[file:]line] [if cond]: Set a breakpoint. Defaults to the current line.
+            If a condition is given, only pause at the breakpoint when it is true.
+        break <func> [if cond]: Pause on entry to the functions with the given name,
+            like main.(*T).Method, or matching a regular expression in single quotes.
+        tbreak: Like break, but the breakpoint is deleted when the debugger first pauses there.
+        clear [[file:]line | func]: Clear a breakpoint set with break, or all of them.
+        info breakpoints: List the breakpoints, including those in the source.
+        enable [n...], disable [n...], delete [n...]: Enable, disable or delete
+            breakpoints by number, or all breakpoints.
+        ignore <n> <count>: Pass over breakpoint n the next count times it is reached.
+        log [[file:]line | func] "msg" [if cond]: Print a message whenever the program
+            reaches a line, without pausing. Expressions in braces, as in "id={id}",
+            are replaced by their values. Logpoints are listed with the breakpoints.
+        goroutines: List the goroutines running instrumented code.
+        goroutine <n>: Follow goroutine n instead, so that next and step continue there.
+        stoptheworld [on|off]: Toggle whether other goroutines wait while the debugger is paused.
+        catch panic [on|off|recovered]: Choose whether to pause when a panic passes
+            through an instrumented function, and whether to report recovered panics.
+        catch error [off | [-]pkg...]: Pause when an instrumented function returns a
+            non-nil error, only in the given packages or not in those marked with -.
+        catch exit [on|off]: Choose whether to pause before os.Exit or log.Fatal ends the program.
+        catch fail [on|off]: Choose whether to pause before a test calls t.Error, t.Fatal or t.Fail.
+        catch: Show what is being caught.
 
 Commands may be given by their full name or by their parenthesized abbreviation.
-Any input that is not one of the above commands is interpreted as a variable name.
+Any input that is not one of the above commands is interpreted as an expression to print.
 
 Pressing enter without typing anything repeats the previous command.
 `
 
 var prevCommand string
 
-func waitForInput(scope *Scope, line int) {
+func waitForInput(c *Context, scope *Scope, line int) {
+	atomic.StoreInt32(&attached, 1)
+	// An interrupt that was waiting for a line to pause at is no longer needed.
+	atomic.StoreInt32(&interrupted, 0)
+	stopWorld()
+	defer startWorld()
+	showDisplays(scope)
+	stack := newStackView(c.g)
+	if f := stack.pausedFrame(scope); f != nil {
+		if stepped && f.paused != nil {
+			printChanges(scope, f.paused, false)
+		}
+		// scope changes when another frame is selected.
+		pausedScope := scope
+		defer func() {
+			f.paused = snapshotVars(pausedScope)
+		}()
+	}
+	reportMissed()
 	for {
 		s, ok := promptUser()
 		if !ok {
@@ -383,35 +556,175 @@ func waitForInput(scope *Scope, line int) {
 			continue
 		case "n", "next":
 			currentState = next
+			stepped = true
 			return
 		case "s", "step":
 			currentState = step
+			stepped = true
 			return
 		case "c", "continue":
 			currentState = run
+			stepped = false
 			return
+		case "finish":
+			if stack.finish() {
+				stepped = false
+				return
+			}
+			continue
+		case "diff":
+			stack.diff()
+			continue
 		case "l", "list":
 			printContext(scope.fileText, line, 4)
 			continue
+		case "locals":
+			printLocals(scope)
+			continue
+		case "args":
+			printArgs(scope)
+			continue
+		case "info scopes":
+			printScopes(scope)
+			continue
+		case "info breakpoints":
+			listBreakpoints()
+			continue
+		case "info display":
+			listDisplays()
+			continue
+		case "bt", "backtrace":
+			stack.backtrace()
+			continue
+		case "goroutines":
+			listGoroutines()
+			continue
+		case "up", "down":
+			delta := 1
+			if s == "down" {
+				delta = -1
+			}
+			if stack.move(delta) {
+				scope, line = stack.current()
+			}
+			continue
 		}
-		if v, ok := scope.getIdent(strings.TrimSpace(s)); ok {
-			fmt.Printf("%#v\n", v)
+		cmd, args := splitCommand(s)
+		if formatCommand(scope, cmd, args) {
 			continue
 		}
-		var cmd, name string
-		n, _ := fmt.Sscan(s, &cmd, &name)
-		if n == 2 && (cmd == "p" || cmd == "print") {
-			if v, ok := scope.getIdent(strings.TrimSpace(name)); ok {
-				fmt.Printf("%#v\n", v)
-				continue
+		switch cmd {
+		case "break", "tbreak":
+			setBreakpoint(scope, line, args, cmd == "tbreak")
+			continue
+		case "enable", "disable", "delete":
+			changeBreakpoints(cmd, args)
+			continue
+		case "ignore":
+			ignoreBreakpoint(args)
+			continue
+		case "log":
+			setLogpoint(scope, line, args)
+			continue
+		case "clear":
+			clearBreakpoints(scope, line, args)
+			continue
+		case "frame":
+			if stack.selectFrameCommand(args) {
+				scope, line = stack.current()
 			}
+			continue
+		case "goroutine":
+			if g := switchGoroutine(args); g != nil {
+				stack = newStackView(g)
+				for i, f := range stack.frames {
+					if f.scope != nil && stack.selectFrame(i) {
+						break
+					}
+				}
+				scope, line = stack.current()
+			}
+			continue
+		case "stoptheworld":
+			toggleStopTheWorld(args)
+			continue
+		case "catch":
+			catch(args)
+			continue
+		case "watch":
+			setWatchpoint(scope, args)
+			continue
+		case "unwatch":
+			deleteWatchpoints(args)
+			continue
+		case "display":
+			setDisplay(scope, args)
+			continue
+		case "undisplay":
+			deleteDisplays(args)
+			continue
+		case "set":
+			setVariable(scope, args)
+			continue
+		case "whatis":
+			if args == "" {
+				break
+			}
+			whatis(scope, args)
+			continue
+		case "ptype":
+			if args == "" {
+				break
+			}
+			ptype(scope, args)
+			continue
+		case "p", "print":
+			if args == "" {
+				break
+			}
+			if v, err := scope.evalString(args); err != nil {
+				fmt.Println(err)
+			} else {
+				printValue(v)
+			}
+			continue
+		}
+		if v, err := scope.evalString(s); err == nil {
+			printValue(v)
+			continue
 		}
 		fmt.Printf("Command not recognized, sorry! You typed: %q\n", s)
 	}
 }
 
-func dereference(i interface{}) interface{} {
-	return reflect.ValueOf(i).Elem().Interface()
+// splitCommand splits s into its first word and the rest of the line.
+func splitCommand(s string) (cmd, args string) {
+	if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// setVariable handles the command "set x = value".
+func setVariable(scope *Scope, args string) {
+	if name, _ := splitCommand(args); name == "print" && !strings.Contains(args, "=") {
+		setPrintLimit(strings.TrimSpace(strings.TrimPrefix(args, "print")))
+		return
+	}
+	lhs, rhs, err := parseAssignment(args)
+	if err == nil {
+		var v value
+		if v, err = scope.assign(lhs, rhs); err == nil {
+			refreshWatchpoints()
+			printValue(v)
+			return
+		}
+	}
+	fmt.Println(err)
+}
+
+func printValue(v value) {
+	fmt.Println(formatValue(v))
 }
 
 func printContext(lines []string, line, contextCount int) {
@@ -0,0 +1,102 @@
+package godebug
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"strconv"
+	"sync"
+)
+
+// A display is an expression that the debugger prints every time it pauses,
+// set from the prompt with the display command.
+type display struct {
+	id   int
+	expr ast.Expr
+	text string
+}
+
+var (
+	displaysMu  sync.Mutex
+	displays    []*display
+	lastDisplay int
+)
+
+// show prints d evaluated in s. It prints nothing if d cannot be evaluated
+// there, as when its variables are not in scope, and reports whether it printed.
+func (d *display) show(s *Scope) bool {
+	v, err := s.eval(d.expr)
+	if err != nil {
+		return false
+	}
+	fmt.Printf("%d: %s = %s\n", d.id, d.text, formatValue(v))
+	return true
+}
+
+// showDisplays prints every display expression that can be evaluated in s.
+func showDisplays(s *Scope) {
+	displaysMu.Lock()
+	defer displaysMu.Unlock()
+	for _, d := range displays {
+		d.show(s)
+	}
+}
+
+// setDisplay handles the command "display [expr]".
+func setDisplay(scope *Scope, args string) {
+	if args == "" {
+		showDisplays(scope)
+		return
+	}
+	e, err := parser.ParseExpr(args)
+	if err != nil {
+		fmt.Printf("Could not parse %q: %v\n", args, err)
+		return
+	}
+	displaysMu.Lock()
+	defer displaysMu.Unlock()
+	lastDisplay++
+	d := &display{id: lastDisplay, expr: e, text: args}
+	displays = append(displays, d)
+	if !d.show(scope) {
+		fmt.Printf("%d: %s will be shown where it can be evaluated.\n", d.id, d.text)
+	}
+}
+
+// deleteDisplays handles the command "undisplay [n]".
+func deleteDisplays(args string) {
+	displaysMu.Lock()
+	defer displaysMu.Unlock()
+	if args == "" {
+		displays = nil
+		fmt.Println("Deleted all display expressions.")
+		return
+	}
+	id, err := strconv.Atoi(args)
+	if err != nil {
+		fmt.Printf("%q is not a display number.\n", args)
+		return
+	}
+	for i, d := range displays {
+		if d.id == id {
+			displays = append(displays[:i], displays[i+1:]...)
+			fmt.Printf("Deleted display %d: %s\n", d.id, d.text)
+			return
+		}
+	}
+	fmt.Printf("There is no display %d.\n", id)
+}
+
+// listDisplays handles the command "info display".
+func listDisplays() {
+	displaysMu.Lock()
+	defer displaysMu.Unlock()
+	if len(displays) == 0 {
+		fmt.Println("There are no display expressions.")
+		return
+	}
+	fmt.Println("Display expressions:")
+	for _, d := range displays {
+		fmt.Printf("%d: %s\n", d.id, d.text)
+	}
+}
@@ -0,0 +1,1018 @@
+package godebug
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"math"
+	"math/big"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// A value is the result of evaluating an expression at the prompt.
+//
+// As in Go, constants are untyped until they are combined with a typed
+// operand. Untyped values are stored with their default type (int, float64,
+// int32, string or bool). The untyped nil is represented by the zero Value.
+type value struct {
+	reflect.Value
+	untyped bool
+}
+
+// evalString parses expr as a Go expression and evaluates it in s.
+func (s *Scope) evalString(expr string) (value, error) {
+	e, err := parser.ParseExpr(expr)
+	if err != nil {
+		return value{}, fmt.Errorf("could not parse %q: %v", expr, err)
+	}
+	return s.eval(e)
+}
+
+// lookup finds the variable or constant called name in s or its ancestors.
+// Variables are returned as addressable values so that they track the program.
+func (s *Scope) lookup(name string) (v value, ok bool) {
+	// TODO: This can race with other goroutines setting the value you are printing.
+	for scope := s; scope != nil; scope = scope.parent {
+		if i, ok := scope.vars[name]; ok {
+			return value{Value: reflect.ValueOf(i).Elem()}, true
+		}
+		if i, ok := scope.consts[name]; ok {
+			rv := reflect.ValueOf(i)
+			untyped := strings.HasPrefix(scope.types[name], "untyped ")
+			if scope.types[name] == "" {
+				// Scope.Constant receives untyped constants converted to their default
+				// types, so without the declared type treat constants of predeclared types as untyped.
+				untyped = rv.Type().PkgPath() == "" && rv.Type().Name() != ""
+			}
+			return value{Value: rv, untyped: untyped}, true
+		}
+	}
+	return value{}, false
+}
+
+func (s *Scope) eval(e ast.Expr) (value, error) {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return s.eval(e.X)
+	case *ast.Ident:
+		return s.evalIdent(e.Name)
+	case *ast.BasicLit:
+		return evalBasicLit(e)
+	case *ast.UnaryExpr:
+		x, err := s.eval(e.X)
+		if err != nil {
+			return value{}, err
+		}
+		return evalUnary(e.Op, x)
+	case *ast.BinaryExpr:
+		return s.evalBinary(e)
+	case *ast.StarExpr:
+		x, err := s.eval(e.X)
+		if err != nil {
+			return value{}, err
+		}
+		if !x.IsValid() || x.Kind() != reflect.Ptr {
+			return value{}, fmt.Errorf("invalid indirect of %s (type %s)", exprString(e.X), typeString(x))
+		}
+		if x.IsNil() {
+			return value{}, fmt.Errorf("%s is a nil pointer", exprString(e.X))
+		}
+		return value{Value: x.Elem()}, nil
+	case *ast.SelectorExpr:
+		return s.evalSelector(e)
+	case *ast.IndexExpr:
+		return s.evalIndex(e)
+	case *ast.SliceExpr:
+		return s.evalSlice(e)
+	case *ast.TypeAssertExpr:
+		return s.evalTypeAssert(e)
+	case *ast.CallExpr:
+		return s.evalCall(e)
+	}
+	return value{}, fmt.Errorf("unsupported expression %s", exprString(e))
+}
+
+// evalOperand is like eval, but rejects untyped nil and reports
+// errors in terms of the expression e.
+func (s *Scope) evalOperand(e ast.Expr) (value, error) {
+	x, err := s.eval(e)
+	if err == nil && !x.IsValid() {
+		err = fmt.Errorf("use of untyped nil in %s", exprString(e))
+	}
+	return x, err
+}
+
+func (s *Scope) evalIdent(name string) (value, error) {
+	if v, ok := s.lookup(name); ok {
+		return v, nil
+	}
+	switch name {
+	case "true", "false":
+		return value{Value: reflect.ValueOf(name == "true"), untyped: true}, nil
+	case "nil":
+		return value{untyped: true}, nil
+	}
+	return value{}, fmt.Errorf("undefined: %s", name)
+}
+
+func evalBasicLit(lit *ast.BasicLit) (value, error) {
+	var (
+		i   interface{}
+		err error
+	)
+	switch lit.Kind {
+	case token.INT:
+		var n int64
+		n, err = strconv.ParseInt(lit.Value, 0, 64)
+		i = int(n)
+	case token.FLOAT:
+		i, err = strconv.ParseFloat(lit.Value, 64)
+	case token.CHAR:
+		var s string
+		if s, err = strconv.Unquote(lit.Value); err == nil {
+			i = []rune(s)[0]
+		}
+	case token.STRING:
+		i, err = strconv.Unquote(lit.Value)
+	default:
+		err = errors.New("unsupported literal")
+	}
+	if err != nil {
+		return value{}, fmt.Errorf("bad literal %s: %v", lit.Value, err)
+	}
+	return value{Value: reflect.ValueOf(i), untyped: true}, nil
+}
+
+func evalUnary(op token.Token, x value) (value, error) {
+	if !x.IsValid() {
+		return value{}, fmt.Errorf("invalid operation: %s nil", op)
+	}
+	switch op {
+	case token.NOT:
+		if x.Kind() == reflect.Bool {
+			return value{Value: reflect.ValueOf(!x.Bool()).Convert(x.Type()), untyped: x.untyped}, nil
+		}
+	case token.ADD:
+		if isNumber(x.Kind()) {
+			return x, nil
+		}
+	case token.SUB:
+		r := reflect.New(x.Type()).Elem()
+		switch {
+		case isInt(x.Kind()):
+			if x.untyped {
+				if err := checkConstant(x.Type(), new(big.Int).Neg(big.NewInt(x.Int()))); err != nil {
+					return value{}, err
+				}
+			}
+			r.SetInt(-x.Int())
+		case isUint(x.Kind()):
+			r.SetUint(-x.Uint())
+		case isFloat(x.Kind()):
+			r.SetFloat(-x.Float())
+		default:
+			return value{}, fmt.Errorf("invalid operation: -%s", x.Type())
+		}
+		return value{Value: r, untyped: x.untyped}, nil
+	case token.XOR:
+		r := reflect.New(x.Type()).Elem()
+		switch {
+		case isInt(x.Kind()):
+			r.SetInt(^x.Int())
+		case isUint(x.Kind()):
+			r.SetUint(^x.Uint())
+			r.SetUint(r.Uint() & (1<<uint(8*x.Type().Size()) - 1))
+		default:
+			return value{}, fmt.Errorf("invalid operation: ^%s", x.Type())
+		}
+		return value{Value: r, untyped: x.untyped}, nil
+	case token.AND:
+		if x.CanAddr() {
+			return value{Value: x.Addr()}, nil
+		}
+		return value{}, errors.New("cannot take the address of a value that is not a variable")
+	}
+	return value{}, fmt.Errorf("invalid operation: %s%s", op, typeString(x))
+}
+
+func (s *Scope) evalBinary(e *ast.BinaryExpr) (value, error) {
+	x, err := s.eval(e.X)
+	if err != nil {
+		return value{}, err
+	}
+
+	// && and || short-circuit, as in Go.
+	if e.Op == token.LAND || e.Op == token.LOR {
+		if !x.IsValid() || x.Kind() != reflect.Bool {
+			return value{}, fmt.Errorf("invalid operation: operator %s not defined on %s", e.Op, typeString(x))
+		}
+		if x.Bool() == (e.Op == token.LOR) {
+			return x, nil
+		}
+		y, err := s.eval(e.Y)
+		if err != nil {
+			return value{}, err
+		}
+		if !y.IsValid() || y.Kind() != reflect.Bool {
+			return value{}, fmt.Errorf("invalid operation: operator %s not defined on %s", e.Op, typeString(y))
+		}
+		return y, nil
+	}
+
+	y, err := s.eval(e.Y)
+	if err != nil {
+		return value{}, err
+	}
+	if e.Op == token.SHL || e.Op == token.SHR {
+		return shift(e.Op, x, y)
+	}
+	if x, y, err = matchTypes(x, y); err != nil {
+		return value{}, err
+	}
+	switch e.Op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		b, err := compare(e.Op, x, y)
+		if err != nil {
+			return value{}, err
+		}
+		return value{Value: reflect.ValueOf(b), untyped: true}, nil
+	}
+	return arith(e.Op, x, y)
+}
+
+// arith applies the arithmetic operator op to x and y, which must have the same type.
+func arith(op token.Token, x, y value) (value, error) {
+	if !x.IsValid() || !y.IsValid() {
+		return value{}, fmt.Errorf("invalid operation: operator %s not defined on nil", op)
+	}
+	r := value{Value: reflect.New(x.Type()).Elem(), untyped: x.untyped && y.untyped}
+	k := x.Kind()
+	switch {
+	case k == reflect.String && op == token.ADD:
+		r.SetString(x.String() + y.String())
+	case isInt(k):
+		a, b := x.Int(), y.Int()
+		if (op == token.QUO || op == token.REM) && b == 0 {
+			return value{}, errors.New("division by zero")
+		}
+		if r.untyped {
+			if err := checkConstant(r.Type(), exactInt(op, a, b)); err != nil {
+				return value{}, err
+			}
+		}
+		switch op {
+		case token.ADD:
+			r.SetInt(a + b)
+		case token.SUB:
+			r.SetInt(a - b)
+		case token.MUL:
+			r.SetInt(a * b)
+		case token.QUO:
+			r.SetInt(a / b)
+		case token.REM:
+			r.SetInt(a % b)
+		case token.AND:
+			r.SetInt(a & b)
+		case token.OR:
+			r.SetInt(a | b)
+		case token.XOR:
+			r.SetInt(a ^ b)
+		case token.AND_NOT:
+			r.SetInt(a &^ b)
+		default:
+			return value{}, fmt.Errorf("invalid operation: operator %s not defined on %s", op, typeString(x))
+		}
+	case isUint(k):
+		a, b := x.Uint(), y.Uint()
+		if (op == token.QUO || op == token.REM) && b == 0 {
+			return value{}, errors.New("division by zero")
+		}
+		switch op {
+		case token.ADD:
+			r.SetUint(a + b)
+		case token.SUB:
+			r.SetUint(a - b)
+		case token.MUL:
+			r.SetUint(a * b)
+		case token.QUO:
+			r.SetUint(a / b)
+		case token.REM:
+			r.SetUint(a % b)
+		case token.AND:
+			r.SetUint(a & b)
+		case token.OR:
+			r.SetUint(a | b)
+		case token.XOR:
+			r.SetUint(a ^ b)
+		case token.AND_NOT:
+			r.SetUint(a &^ b)
+		default:
+			return value{}, fmt.Errorf("invalid operation: operator %s not defined on %s", op, typeString(x))
+		}
+	case isFloat(k):
+		a, b := x.Float(), y.Float()
+		switch op {
+		case token.ADD:
+			r.SetFloat(a + b)
+		case token.SUB:
+			r.SetFloat(a - b)
+		case token.MUL:
+			r.SetFloat(a * b)
+		case token.QUO:
+			if b == 0 && r.untyped {
+				return value{}, errors.New("division by zero")
+			}
+			r.SetFloat(a / b)
+		default:
+			return value{}, fmt.Errorf("invalid operation: operator %s not defined on %s", op, typeString(x))
+		}
+		if r.untyped && math.IsInf(r.Float(), 0) {
+			return value{}, fmt.Errorf("constant %s %s %s overflows %s", formatConst(x), op, formatConst(y), r.Type())
+		}
+	default:
+		return value{}, fmt.Errorf("invalid operation: operator %s not defined on %s", op, typeString(x))
+	}
+	return r, nil
+}
+
+// shift applies the shift operator op to x and y. Unlike other binary
+// operators, the operands of a shift need not have the same type.
+func shift(op token.Token, x, y value) (value, error) {
+	var n uint64
+	switch {
+	case y.IsValid() && isUint(y.Kind()):
+		n = y.Uint()
+	case y.IsValid() && isInt(y.Kind()) && y.Int() >= 0:
+		n = uint64(y.Int())
+	default:
+		return value{}, fmt.Errorf("invalid shift count %s", typeString(y))
+	}
+	if !x.IsValid() || !isInt(x.Kind()) && !isUint(x.Kind()) {
+		return value{}, fmt.Errorf("invalid operation: shift of type %s", typeString(x))
+	}
+	r := value{Value: reflect.New(x.Type()).Elem(), untyped: x.untyped}
+	if r.untyped && op == token.SHL && x.Int() != 0 {
+		if n >= 512 {
+			return value{}, fmt.Errorf("constant %d << %d overflows %s", x.Int(), n, r.Type())
+		}
+		if err := checkConstant(r.Type(), new(big.Int).Lsh(big.NewInt(x.Int()), uint(n))); err != nil {
+			return value{}, err
+		}
+	}
+	switch {
+	case isInt(x.Kind()) && op == token.SHL:
+		r.SetInt(x.Int() << n)
+	case isInt(x.Kind()):
+		r.SetInt(x.Int() >> n)
+	case op == token.SHL:
+		r.SetUint(x.Uint() << n)
+	default:
+		r.SetUint(x.Uint() >> n)
+	}
+	return r, nil
+}
+
+// exactInt returns the result of the operator op on the integer constants a
+// and b, computed exactly as Go computes untyped constants, or nil if op
+// cannot overflow.
+func exactInt(op token.Token, a, b int64) *big.Int {
+	x, y := big.NewInt(a), big.NewInt(b)
+	switch op {
+	case token.ADD:
+		return x.Add(x, y)
+	case token.SUB:
+		return x.Sub(x, y)
+	case token.MUL:
+		return x.Mul(x, y)
+	case token.QUO:
+		return x.Quo(x, y)
+	}
+	return nil
+}
+
+var (
+	minInt64 = big.NewInt(math.MinInt64)
+	maxInt64 = big.NewInt(math.MaxInt64)
+)
+
+// checkConstant reports an error if the untyped integer constant z does not
+// fit in t. Untyped constants are held in their default types, so a constant
+// expression that Go would reject for overflowing int must not wrap around.
+func checkConstant(t reflect.Type, z *big.Int) error {
+	if z == nil {
+		return nil
+	}
+	if z.Cmp(minInt64) < 0 || z.Cmp(maxInt64) > 0 || reflect.New(t).Elem().OverflowInt(z.Int64()) {
+		return fmt.Errorf("constant %s overflows %s", z, t)
+	}
+	return nil
+}
+
+func (s *Scope) evalSelector(e *ast.SelectorExpr) (value, error) {
+	x, err := s.evalOperand(e.X)
+	if err != nil {
+		return value{}, err
+	}
+	name := e.Sel.Name
+	t := x.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return value{}, fmt.Errorf("%s undefined (type %s has no field %s)", exprString(e), x.Type(), name)
+	}
+	f, ok := t.FieldByName(name)
+	if !ok {
+		return value{}, fmt.Errorf("%s undefined (type %s has no field %s)", exprString(e), x.Type(), name)
+	}
+	// Walk the path to the field ourselves rather than calling FieldByName
+	// on the value, so that a nil embedded pointer is an error, not a panic.
+	v := x.Value
+	for _, i := range f.Index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return value{}, fmt.Errorf("%s: nil pointer dereference", exprString(e))
+			}
+			v = v.Elem()
+		}
+		v = accessible(addressable(v).Field(i))
+	}
+	return value{Value: v}, nil
+}
+
+func (s *Scope) evalIndex(e *ast.IndexExpr) (value, error) {
+	x, err := s.evalOperand(e.X)
+	if err != nil {
+		return value{}, err
+	}
+	idx, err := s.eval(e.Index)
+	if err != nil {
+		return value{}, err
+	}
+	if x.Kind() == reflect.Ptr && x.Type().Elem().Kind() == reflect.Array {
+		if x.IsNil() {
+			return value{}, fmt.Errorf("%s is a nil pointer", exprString(e.X))
+		}
+		x = value{Value: x.Elem()}
+	}
+	switch x.Kind() {
+	case reflect.Map:
+		if idx, err = convertTo(idx, x.Type().Key()); err != nil {
+			return value{}, err
+		}
+		v := x.MapIndex(idx.Value)
+		if !v.IsValid() {
+			v = reflect.Zero(x.Type().Elem())
+		}
+		return value{Value: v}, nil
+	case reflect.Slice, reflect.Array, reflect.String:
+		i, err := indexValue(idx, x.Len(), false)
+		if err != nil {
+			return value{}, err
+		}
+		if x.Kind() == reflect.Array {
+			x.Value = addressable(x.Value)
+		}
+		return value{Value: accessible(x.Index(i))}, nil
+	}
+	return value{}, fmt.Errorf("invalid operation: %s (type %s does not support indexing)", exprString(e), x.Type())
+}
+
+func (s *Scope) evalSlice(e *ast.SliceExpr) (value, error) {
+	x, err := s.evalOperand(e.X)
+	if err != nil {
+		return value{}, err
+	}
+	if x.Kind() == reflect.Ptr && x.Type().Elem().Kind() == reflect.Array {
+		if x.IsNil() {
+			return value{}, fmt.Errorf("%s is a nil pointer", exprString(e.X))
+		}
+		x = value{Value: x.Elem()}
+	}
+	switch x.Kind() {
+	case reflect.Slice, reflect.String:
+	case reflect.Array:
+		x.Value = addressable(x.Value)
+	default:
+		return value{}, fmt.Errorf("cannot slice %s (type %s)", exprString(e.X), x.Type())
+	}
+	max := x.Len()
+	if x.Kind() != reflect.String {
+		max = x.Cap()
+	}
+	indices := []int{0, x.Len(), max}
+	for i, ie := range []ast.Expr{e.Low, e.High, e.Max} {
+		if ie == nil {
+			continue
+		}
+		iv, err := s.eval(ie)
+		if err != nil {
+			return value{}, err
+		}
+		if indices[i], err = indexValue(iv, max, true); err != nil {
+			return value{}, err
+		}
+	}
+	if indices[0] > indices[1] || indices[1] > indices[2] {
+		return value{}, fmt.Errorf("invalid slice indices: %d, %d, %d", indices[0], indices[1], indices[2])
+	}
+	if e.Slice3 {
+		return value{Value: x.Slice3(indices[0], indices[1], indices[2])}, nil
+	}
+	return value{Value: x.Slice(indices[0], indices[1])}, nil
+}
+
+// indexValue checks that v is a valid index for a value of length n.
+// For slice expressions, n itself is a valid index.
+func indexValue(v value, n int, slicing bool) (int, error) {
+	var i int64
+	switch {
+	case v.IsValid() && isInt(v.Kind()):
+		i = v.Int()
+	case v.IsValid() && isUint(v.Kind()) && v.Uint() <= uint64(n):
+		i = int64(v.Uint())
+	case v.IsValid() && isUint(v.Kind()):
+		i = int64(n) + 1
+	case v.IsValid() && isFloat(v.Kind()) && v.untyped && v.Float() == float64(int64(v.Float())):
+		i = int64(v.Float())
+	default:
+		return 0, fmt.Errorf("invalid index of type %s", typeString(v))
+	}
+	if i < 0 || i > int64(n) || i == int64(n) && !slicing {
+		return 0, fmt.Errorf("index out of range [%d] with length %d", i, n)
+	}
+	return int(i), nil
+}
+
+func (s *Scope) evalTypeAssert(e *ast.TypeAssertExpr) (value, error) {
+	x, err := s.evalOperand(e.X)
+	if err != nil {
+		return value{}, err
+	}
+	if e.Type == nil {
+		return value{}, errors.New("use of .(type) outside type switch")
+	}
+	if x.Kind() != reflect.Interface {
+		return value{}, fmt.Errorf("invalid type assertion: %s (non-interface type %s on left)", exprString(e), x.Type())
+	}
+	if x.IsNil() {
+		return value{}, fmt.Errorf("interface conversion: %s is nil, not %s", exprString(e.X), exprString(e.Type))
+	}
+	dyn := x.Elem()
+	if iface, ok := builtinTypes[exprString(e.Type)]; ok && iface.Kind() == reflect.Interface {
+		if !dyn.Type().Implements(iface) {
+			return value{}, fmt.Errorf("interface conversion: %s does not implement %s", dyn.Type(), exprString(e.Type))
+		}
+		return value{Value: dyn.Convert(iface)}, nil
+	}
+	if !typeMatches(dyn.Type(), e.Type) {
+		return value{}, fmt.Errorf("interface conversion: %s is %s, not %s", exprString(e.X), dyn.Type(), exprString(e.Type))
+	}
+	return value{Value: dyn}, nil
+}
+
+// builtinTypes are the types that can be named in a type assertion
+// independently of the program being debugged.
+var builtinTypes = map[string]reflect.Type{
+	"bool":        reflect.TypeOf(false),
+	"string":      reflect.TypeOf(""),
+	"int":         reflect.TypeOf(int(0)),
+	"int8":        reflect.TypeOf(int8(0)),
+	"int16":       reflect.TypeOf(int16(0)),
+	"int32":       reflect.TypeOf(int32(0)),
+	"rune":        reflect.TypeOf(rune(0)),
+	"int64":       reflect.TypeOf(int64(0)),
+	"uint":        reflect.TypeOf(uint(0)),
+	"uint8":       reflect.TypeOf(uint8(0)),
+	"byte":        reflect.TypeOf(byte(0)),
+	"uint16":      reflect.TypeOf(uint16(0)),
+	"uint32":      reflect.TypeOf(uint32(0)),
+	"uint64":      reflect.TypeOf(uint64(0)),
+	"uintptr":     reflect.TypeOf(uintptr(0)),
+	"float32":     reflect.TypeOf(float32(0)),
+	"float64":     reflect.TypeOf(float64(0)),
+	"complex64":   reflect.TypeOf(complex64(0)),
+	"complex128":  reflect.TypeOf(complex128(0)),
+	"error":       reflect.TypeOf((*error)(nil)).Elem(),
+	"interface{}": reflect.TypeOf((*interface{})(nil)).Elem(),
+}
+
+// typeMatches reports whether the type expression e could denote t. The
+// debugger has no type information for the program other than what reflect
+// provides, so a named type matches by its name and, if given, package name.
+func typeMatches(t reflect.Type, e ast.Expr) bool {
+	if b, ok := builtinTypes[exprString(e)]; ok {
+		return t == b
+	}
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return typeMatches(t, e.X)
+	case *ast.Ident:
+		return t.Name() == e.Name
+	case *ast.SelectorExpr:
+		pkg, ok := e.X.(*ast.Ident)
+		return ok && t.Name() == e.Sel.Name && path.Base(t.PkgPath()) == pkg.Name
+	case *ast.StarExpr:
+		return t.Kind() == reflect.Ptr && typeMatches(t.Elem(), e.X)
+	case *ast.ArrayType:
+		if e.Len == nil {
+			return t.Kind() == reflect.Slice && typeMatches(t.Elem(), e.Elt)
+		}
+		return t.Kind() == reflect.Array && exprString(e.Len) == strconv.Itoa(t.Len()) && typeMatches(t.Elem(), e.Elt)
+	case *ast.MapType:
+		return t.Kind() == reflect.Map && typeMatches(t.Key(), e.Key) && typeMatches(t.Elem(), e.Value)
+	}
+	return t.Name() == "" && t.String() == exprString(e)
+}
+
+func (s *Scope) evalCall(e *ast.CallExpr) (value, error) {
+	fn, ok := e.Fun.(*ast.Ident)
+	if !ok || fn.Name != "len" && fn.Name != "cap" {
+		return value{}, fmt.Errorf("cannot call %s: only len and cap may be called from the prompt", exprString(e.Fun))
+	}
+	if _, shadowed := s.lookup(fn.Name); shadowed {
+		return value{}, fmt.Errorf("cannot call non-function %s", fn.Name)
+	}
+	if len(e.Args) != 1 {
+		return value{}, fmt.Errorf("wrong number of arguments to %s", fn.Name)
+	}
+	x, err := s.evalOperand(e.Args[0])
+	if err != nil {
+		return value{}, err
+	}
+	if x.Kind() == reflect.Ptr && x.Type().Elem().Kind() == reflect.Array {
+		return value{Value: reflect.ValueOf(x.Type().Elem().Len())}, nil
+	}
+	switch k := x.Kind(); {
+	case fn.Name == "len" && (k == reflect.String || k == reflect.Map):
+		return value{Value: reflect.ValueOf(x.Len())}, nil
+	case k == reflect.Slice || k == reflect.Array || k == reflect.Chan:
+		if fn.Name == "cap" {
+			return value{Value: reflect.ValueOf(x.Cap())}, nil
+		}
+		return value{Value: reflect.ValueOf(x.Len())}, nil
+	}
+	return value{}, fmt.Errorf("invalid argument %s (type %s) for %s", exprString(e.Args[0]), x.Type(), fn.Name)
+}
+
+// addressable returns v if it is addressable, or an addressable copy of it otherwise.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// accessible returns v, or if v was reached through an unexported struct
+// field, an equivalent value that reflect allows the debugger to read.
+func accessible(v reflect.Value) reflect.Value {
+	if v.CanInterface() {
+		return v
+	}
+	if !v.CanAddr() {
+		v = addressable(v)
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// convertTo converts v so that it can be used where a value of type t is
+// expected, as when indexing a map with key type t.
+func convertTo(v value, t reflect.Type) (value, error) {
+	switch {
+	case v.untyped:
+		return convertUntyped(v, t)
+	case v.Type() == t:
+		return v, nil
+	case v.Type().AssignableTo(t):
+		return value{Value: v.Convert(t)}, nil
+	}
+	return value{}, fmt.Errorf("cannot use value of type %s as type %s", v.Type(), t)
+}
+
+// parseAssignment parses stmt, which must have the form "lhs = rhs".
+func parseAssignment(stmt string) (lhs, rhs ast.Expr, err error) {
+	src := "package p; func _() { " + stmt + "\n}"
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not parse %q", stmt)
+	}
+	body := f.Decls[0].(*ast.FuncDecl).Body.List
+	if len(body) == 1 {
+		if a, ok := body[0].(*ast.AssignStmt); ok && a.Tok == token.ASSIGN && len(a.Lhs) == 1 && len(a.Rhs) == 1 {
+			return a.Lhs[0], a.Rhs[0], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%q is not an assignment of the form x = value", stmt)
+}
+
+// assign evaluates rhs and stores it in the variable denoted by lhs, in the
+// same way as the Go assignment lhs = rhs. It returns the assigned value.
+func (s *Scope) assign(lhs, rhs ast.Expr) (value, error) {
+	if idx, ok := unparen(lhs).(*ast.IndexExpr); ok {
+		if m, err := s.evalOperand(idx.X); err == nil && m.Kind() == reflect.Map {
+			return s.assignMapIndex(m, idx, rhs)
+		}
+	}
+	if err := s.checkAssignable(lhs); err != nil {
+		return value{}, err
+	}
+	dst, err := s.eval(lhs)
+	if err != nil {
+		return value{}, err
+	}
+	if !dst.CanSet() {
+		return value{}, fmt.Errorf("cannot assign to %s", exprString(lhs))
+	}
+	v, err := s.eval(rhs)
+	if err != nil {
+		return value{}, err
+	}
+	if v, err = convertTo(v, dst.Type()); err != nil {
+		return value{}, err
+	}
+	dst.Set(v.Value)
+	return dst, nil
+}
+
+func (s *Scope) assignMapIndex(m value, idx *ast.IndexExpr, rhs ast.Expr) (value, error) {
+	if m.IsNil() {
+		return value{}, fmt.Errorf("cannot assign to %s: assignment to entry in nil map", exprString(idx))
+	}
+	k, err := s.eval(idx.Index)
+	if err != nil {
+		return value{}, err
+	}
+	if k, err = convertTo(k, m.Type().Key()); err != nil {
+		return value{}, err
+	}
+	v, err := s.eval(rhs)
+	if err != nil {
+		return value{}, err
+	}
+	if v, err = convertTo(v, m.Type().Elem()); err != nil {
+		return value{}, err
+	}
+	m.SetMapIndex(k.Value, v.Value)
+	return v, nil
+}
+
+// checkAssignable reports an error if e is not addressable in the sense of the
+// Go spec. Evaluating e may make copies of values that are not addressable, and
+// assigning to those would silently have no effect on the program.
+func (s *Scope) checkAssignable(e ast.Expr) error {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return s.checkAssignable(e.X)
+	case *ast.Ident:
+		for scope := s; scope != nil; scope = scope.parent {
+			if _, ok := scope.vars[e.Name]; ok {
+				return nil
+			}
+			if _, ok := scope.consts[e.Name]; ok {
+				return fmt.Errorf("cannot assign to %s (declared const)", e.Name)
+			}
+		}
+		return fmt.Errorf("undefined: %s", e.Name)
+	case *ast.StarExpr:
+		return nil
+	case *ast.SelectorExpr:
+		if x, err := s.evalOperand(e.X); err == nil && x.Kind() == reflect.Ptr {
+			return nil
+		}
+		return s.checkAssignable(e.X)
+	case *ast.IndexExpr:
+		x, err := s.evalOperand(e.X)
+		if err != nil {
+			return err
+		}
+		switch x.Kind() {
+		case reflect.Slice, reflect.Ptr:
+			return nil
+		case reflect.Array:
+			return s.checkAssignable(e.X)
+		case reflect.Map:
+			return fmt.Errorf("cannot assign to %s (map elements are not addressable)", exprString(e))
+		case reflect.String:
+			return fmt.Errorf("cannot assign to %s (strings are immutable)", exprString(e))
+		}
+	}
+	return fmt.Errorf("cannot assign to %s", exprString(e))
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+// matchTypes converts an untyped operand to the type of the other operand,
+// or reports an error if the operands' types are incompatible.
+func matchTypes(x, y value) (value, value, error) {
+	var err error
+	switch {
+	case x.untyped && y.untyped:
+		if x.IsValid() && y.IsValid() && isNumber(x.Kind()) && isNumber(y.Kind()) && x.Type() != y.Type() {
+			// Mixed untyped numeric constants take the "larger" kind, as in Go.
+			if isFloat(y.Kind()) || !isFloat(x.Kind()) && y.Kind() == reflect.Int32 {
+				x, err = convertUntyped(x, y.Type())
+			} else {
+				y, err = convertUntyped(y, x.Type())
+			}
+			x.untyped, y.untyped = true, true
+		}
+		if err == nil && x.IsValid() && y.IsValid() && x.Type() != y.Type() {
+			err = fmt.Errorf("mismatched types %s and %s", typeString(x), typeString(y))
+		}
+	case x.untyped:
+		x, err = convertUntyped(x, y.Type())
+	case y.untyped:
+		y, err = convertUntyped(y, x.Type())
+	case x.Type() != y.Type():
+		switch {
+		case x.Kind() == reflect.Interface && y.Type().Implements(x.Type()):
+			y = value{Value: y.Convert(x.Type())}
+		case y.Kind() == reflect.Interface && x.Type().Implements(y.Type()):
+			x = value{Value: x.Convert(y.Type())}
+		default:
+			err = fmt.Errorf("mismatched types %s and %s", x.Type(), y.Type())
+		}
+	}
+	return x, y, err
+}
+
+// convertUntyped converts the untyped value v to type t, following the
+// rules for assigning untyped constants in Go.
+func convertUntyped(v value, t reflect.Type) (value, error) {
+	if !v.IsValid() {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface, reflect.UnsafePointer:
+			return value{Value: reflect.Zero(t)}, nil
+		}
+		return value{}, fmt.Errorf("cannot use nil as type %s", t)
+	}
+	if t.Kind() == reflect.Interface {
+		if !v.Type().Implements(t) {
+			return value{}, fmt.Errorf("cannot use %s as type %s", v.Type(), t)
+		}
+		return value{Value: v.Convert(t)}, nil
+	}
+	r := reflect.New(t).Elem()
+	bad := false
+	switch {
+	case v.Kind() == reflect.Bool && t.Kind() == reflect.Bool:
+		r.SetBool(v.Bool())
+	case v.Kind() == reflect.String && t.Kind() == reflect.String:
+		r.SetString(v.String())
+	case isInt(v.Kind()) && isInt(t.Kind()):
+		bad = r.OverflowInt(v.Int())
+		r.SetInt(v.Int())
+	case isInt(v.Kind()) && isUint(t.Kind()):
+		bad = v.Int() < 0 || r.OverflowUint(uint64(v.Int()))
+		r.SetUint(uint64(v.Int()))
+	case isInt(v.Kind()) && isFloat(t.Kind()):
+		r.SetFloat(float64(v.Int()))
+	case isFloat(v.Kind()) && isFloat(t.Kind()):
+		r.SetFloat(v.Float())
+	case isFloat(v.Kind()) && isInt(t.Kind()):
+		bad = v.Float() != float64(int64(v.Float())) || r.OverflowInt(int64(v.Float()))
+		r.SetInt(int64(v.Float()))
+	case isFloat(v.Kind()) && isUint(t.Kind()):
+		bad = v.Float() < 0 || v.Float() != float64(uint64(v.Float())) || r.OverflowUint(uint64(v.Float()))
+		r.SetUint(uint64(v.Float()))
+	default:
+		bad = true
+	}
+	if bad {
+		return value{}, fmt.Errorf("cannot use %s (untyped constant) as type %s", formatConst(v), t)
+	}
+	return value{Value: r}, nil
+}
+
+// compare applies the comparison operator op to x and y, which must have the same type.
+func compare(op token.Token, x, y value) (bool, error) {
+	if !x.IsValid() || !y.IsValid() {
+		return false, fmt.Errorf("invalid operation: nil %s nil", op)
+	}
+	var c int // -1, 0 or 1 for ordered types
+	switch k := x.Kind(); {
+	case isInt(k):
+		c = cmpOrdered(x.Int() < y.Int(), x.Int() > y.Int())
+	case isUint(k):
+		c = cmpOrdered(x.Uint() < y.Uint(), x.Uint() > y.Uint())
+	case isFloat(k):
+		c = cmpOrdered(x.Float() < y.Float(), x.Float() > y.Float())
+	case k == reflect.String:
+		c = cmpOrdered(x.String() < y.String(), x.String() > y.String())
+	default:
+		if op != token.EQL && op != token.NEQ {
+			return false, fmt.Errorf("invalid operation: operator %s not defined on %s", op, x.Type())
+		}
+		eq, err := equal(x, y)
+		return eq == (op == token.EQL), err
+	}
+	switch op {
+	case token.EQL:
+		return c == 0, nil
+	case token.NEQ:
+		return c != 0, nil
+	case token.LSS:
+		return c < 0, nil
+	case token.LEQ:
+		return c <= 0, nil
+	case token.GTR:
+		return c > 0, nil
+	}
+	return c >= 0, nil // token.GEQ
+}
+
+func cmpOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// equal reports whether x and y, which have the same type, are equal.
+func equal(x, y value) (eq bool, err error) {
+	switch x.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Func:
+		// These are only comparable to nil.
+		if x.IsNil() || y.IsNil() {
+			return x.IsNil() && y.IsNil(), nil
+		}
+		return false, fmt.Errorf("invalid operation: %s can only be compared to nil", x.Type())
+	case reflect.Bool:
+		return x.Bool() == y.Bool(), nil
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return x.Pointer() == y.Pointer(), nil
+	}
+	if !x.Type().Comparable() {
+		return false, fmt.Errorf("invalid operation: %s cannot be compared", x.Type())
+	}
+	if !x.CanInterface() || !y.CanInterface() {
+		return false, errors.New("cannot compare values of unexported fields")
+	}
+	defer func() {
+		// Interfaces holding incomparable dynamic types panic when compared.
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return x.Interface() == y.Interface(), nil
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+func isNumber(k reflect.Kind) bool {
+	return isInt(k) || isUint(k) || isFloat(k)
+}
+
+func typeString(v value) string {
+	switch {
+	case !v.IsValid():
+		return "nil"
+	case v.untyped:
+		return "untyped " + v.Type().String()
+	}
+	return v.Type().String()
+}
+
+func formatConst(v value) string {
+	if v.Kind() == reflect.String {
+		return strconv.Quote(v.String())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func exprString(e ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), e)
+	return buf.String()
+}
@@ -0,0 +1,169 @@
+package godebug
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A printFormat is the modifier of a print command, as in p/x. It changes
+// how the integers or byte slices anywhere in the printed value are shown.
+type printFormat string
+
+const (
+	formatDefault printFormat = ""
+	formatHex     printFormat = "x"
+	formatBinary  printFormat = "b"
+	formatChar    printFormat = "c"
+	formatDecimal printFormat = "d"
+	formatString  printFormat = "s"   // byte and rune slices and arrays as strings
+	formatRaw     printFormat = "raw" // what %#v prints, as before there were formats
+)
+
+func parsePrintFormat(s string) (printFormat, error) {
+	switch f := printFormat(s); f {
+	case formatHex, formatBinary, formatChar, formatDecimal, formatString, formatRaw:
+		return f, nil
+	}
+	return "", fmt.Errorf("%q is not a print format. The formats are x (hex), b (binary), c (character), d (decimal), s (string) and raw.", s)
+}
+
+// formatCommand handles the commands "p/<format> <expr>" and "x/<n> <expr>".
+// It reports whether cmd and args were one of them.
+func formatCommand(scope *Scope, cmd, args string) bool {
+	i := strings.Index(cmd, "/")
+	if i < 0 || args == "" {
+		return false
+	}
+	switch name, modifier := cmd[:i], cmd[i+1:]; name {
+	case "p", "print":
+		f, err := parsePrintFormat(modifier)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		if v, err := scope.evalString(args); err != nil {
+			fmt.Println(err)
+		} else {
+			fmt.Println(formatValueAs(v, f))
+		}
+	case "x":
+		examine(scope, modifier, args)
+	default:
+		return false
+	}
+	return true
+}
+
+// formatted formats v if p's format applies to it.
+func (p *valuePrinter) formatted(v reflect.Value) (*pretty, bool) {
+	switch k := v.Kind(); {
+	case p.f == formatDefault:
+	case isInt(k):
+		return p.formatInt(v.Int() < 0, absInt(v.Int()))
+	case isUint(k):
+		return p.formatInt(false, v.Uint())
+	case p.f == formatString && (k == reflect.Slice || k == reflect.Array):
+		switch v.Type().Elem().Kind() {
+		case reflect.Uint8:
+			return leaf(p.quote(string(bytesIn(v))), false).withType(v.Type()), true
+		case reflect.Int32:
+			r := make([]rune, v.Len())
+			for i := range r {
+				r[i] = rune(v.Index(i).Int())
+			}
+			return leaf(p.quote(string(r)), false).withType(v.Type()), true
+		}
+	}
+	return nil, false
+}
+
+func absInt(n int64) uint64 {
+	if n < 0 {
+		return uint64(-n)
+	}
+	return uint64(n)
+}
+
+func (p *valuePrinter) formatInt(negative bool, n uint64) (*pretty, bool) {
+	sign := ""
+	if negative {
+		sign = "-"
+	}
+	switch p.f {
+	case formatHex:
+		return leaf(sign+"0x"+strconv.FormatUint(n, 16), false), true
+	case formatBinary:
+		return leaf(sign+"0b"+strconv.FormatUint(n, 2), false), true
+	case formatDecimal:
+		return leaf(sign+strconv.FormatUint(n, 10), false), true
+	case formatChar:
+		if !negative && n <= utf8.MaxRune && utf8.ValidRune(rune(n)) {
+			return leaf(strconv.QuoteRune(rune(n)), false), true
+		}
+	}
+	return nil, false
+}
+
+// examine handles the command "x/<n> <expr>", which shows the first
+// n bytes of a byte slice, byte array or string as a hex dump.
+func examine(scope *Scope, count, expr string) {
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		fmt.Printf("%q is not a number of bytes. Give one after x/, as in \"x/64 buf\".\n", count)
+		return
+	}
+	v, err := scope.evalString(expr)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	b, ok := bytesOf(v)
+	if !ok {
+		fmt.Printf("x shows the bytes of a []byte, a byte array or a string, but %s is a %s.\n", expr, typeString(v))
+		return
+	}
+	if len(b) == 0 {
+		fmt.Printf("%s is empty.\n", expr)
+		return
+	}
+	more := len(b) - n
+	if more > 0 {
+		b = b[:n]
+	}
+	fmt.Print(hex.Dump(b))
+	if more > 0 {
+		fmt.Printf("... (%d more bytes)\n", more)
+	}
+}
+
+// bytesOf returns the bytes of v if it is a string or a slice or array of bytes.
+func bytesOf(v value) ([]byte, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	rv := accessible(v.Value)
+	switch rv.Kind() {
+	case reflect.String:
+		return []byte(rv.String()), true
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			return nil, false
+		}
+		return bytesIn(rv), true
+	}
+	return nil, false
+}
+
+// bytesIn returns the elements of v, a slice or array of a byte type. It copies them
+// one by one, since reflect.Copy rejects named types like "type flag byte".
+func bytesIn(v reflect.Value) []byte {
+	b := make([]byte, v.Len())
+	for i := range b {
+		b[i] = byte(v.Index(i).Uint())
+	}
+	return b
+}
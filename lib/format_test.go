@@ -0,0 +1,37 @@
+package godebug
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type (
+	flag   byte
+	letter rune
+)
+
+func TestFormatNamedBytes(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{[]flag("hi"), `"hi"`},
+		{[3]flag{'a', 'b', 'c'}, `"abc"`},
+		{[]letter("héllo"), `"héllo"`},
+		{[2]letter{'o', 'k'}, `"ok"`},
+	}
+	for _, tt := range tests {
+		if got := formatValueAs(value{Value: reflect.ValueOf(tt.v)}, formatString); !strings.Contains(got, tt.want) {
+			t.Errorf("p/s of %#v = %s, want it to show %s", tt.v, got, tt.want)
+		}
+	}
+
+	for _, v := range []interface{}{[]flag("abc"), [3]flag{'a', 'b', 'c'}} {
+		b, ok := bytesOf(value{Value: reflect.ValueOf(v)})
+		if !ok || !bytes.Equal(b, []byte("abc")) {
+			t.Errorf("bytesOf(%#v) = %q, %v, want \"abc\", true", v, b, ok)
+		}
+	}
+}
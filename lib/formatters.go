@@ -0,0 +1,49 @@
+package godebug
+
+import (
+	"reflect"
+	"sync"
+)
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = make(map[reflect.Type]func(v interface{}) string)
+)
+
+// RegisterFormatter makes the debugger print values of the type of sample with
+// fn instead of showing what is in them. It is meant to be called from the init
+// function of an instrumented package or of a test file, as in:
+//
+//	func init() {
+//		godebug.RegisterFormatter(Money{}, func(v interface{}) string {
+//			m := v.(Money)
+//			return fmt.Sprintf("%d.%02d %s", m.Cents/100, m.Cents%100, m.Currency)
+//		})
+//	}
+//
+// fn is used wherever the type appears, including inside other values, except by
+// p/raw. Registering a formatter for a type again replaces the previous one.
+func RegisterFormatter(sample interface{}, fn func(v interface{}) string) {
+	if sample == nil {
+		panic("godebug: RegisterFormatter needs a sample value of the type to format, not nil")
+	}
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[reflect.TypeOf(sample)] = fn
+}
+
+// registered formats v with the formatter registered for its type, if there is
+// one. If the formatter panics, v is shown as if there were none.
+func (p *valuePrinter) registered(v reflect.Value) (*pretty, bool) {
+	formattersMu.RLock()
+	fn := formatters[v.Type()]
+	formattersMu.RUnlock()
+	if fn == nil {
+		return nil, false
+	}
+	text, err := call(func() string { return fn(v.Interface()) })
+	if err != nil {
+		return nil, false
+	}
+	return leaf(text, false), true
+}
@@ -0,0 +1,46 @@
+package godebug
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Pressing Ctrl-C while the program is running, after "continue", pauses at
+// the next line that any instrumented goroutine runs. That goroutine becomes
+// the one the debugger follows. Pressing Ctrl-C again before that happens,
+// within interruptWindow of the first time, or while the debugger is paused,
+// ends the program as it would without the debugger.
+var (
+	// interrupted is set while an interrupt is waiting for a line to pause at.
+	interrupted int32
+
+	// lastInterrupt is when the program was last interrupted.
+	// It is only used by the goroutine that receives signals.
+	lastInterrupt time.Time
+)
+
+const interruptWindow = 2 * time.Second
+
+// interrupt is called when the user presses Ctrl-C. It reports whether the
+// debugger will handle it, rather than the program ending.
+func interrupt(now time.Time) bool {
+	if atomic.LoadInt32(&currentState) != run || atomic.LoadInt32(&interrupted) != 0 || now.Sub(lastInterrupt) < interruptWindow {
+		return false
+	}
+	lastInterrupt = now
+	atomic.StoreInt32(&interrupted, 1)
+	fmt.Println("\nInterrupted. The debugger will pause at the next line an instrumented goroutine runs. Press Ctrl-C again to quit.")
+	return true
+}
+
+// hitInterrupt reports whether the debugger should pause because the program was
+// interrupted, and if so makes the goroutine c belongs to the one it follows.
+func hitInterrupt(c *Context) bool {
+	if atomic.LoadInt32(&interrupted) == 0 || !atomic.CompareAndSwapInt32(&interrupted, 1, 0) {
+		return false
+	}
+	atomic.StoreInt32(&currentState, step)
+	atomic.StoreUint32(&currentGoroutine, c.goroutine)
+	return true
+}
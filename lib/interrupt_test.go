@@ -0,0 +1,45 @@
+package godebug
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInterrupt(t *testing.T) {
+	savedState, savedGoroutine, savedLast := currentState, currentGoroutine, lastInterrupt
+	defer func() {
+		currentState, currentGoroutine, lastInterrupt = savedState, savedGoroutine, savedLast
+		atomic.StoreInt32(&interrupted, 0)
+	}()
+	currentState, lastInterrupt = run, time.Time{}
+
+	start := time.Now()
+	if !interrupt(start) {
+		t.Fatal("first Ctrl-C: the debugger did not handle it")
+	}
+	if interrupt(start.Add(time.Second)) {
+		t.Error("second Ctrl-C before any line ran: the debugger handled it instead of quitting")
+	}
+
+	if !hitInterrupt(&Context{goroutine: 7}) {
+		t.Fatal("the line after Ctrl-C did not pause")
+	}
+	if currentState != step || currentGoroutine != 7 {
+		t.Errorf("after pausing for Ctrl-C: state %d, goroutine %d, want %d, 7", currentState, currentGoroutine, step)
+	}
+	if hitInterrupt(&Context{goroutine: 8}) {
+		t.Error("a second line paused for the same Ctrl-C")
+	}
+	if interrupt(start.Add(time.Second)) {
+		t.Error("Ctrl-C while paused: the debugger handled it instead of quitting")
+	}
+
+	currentState = run
+	if interrupt(start.Add(interruptWindow - time.Millisecond)) {
+		t.Errorf("Ctrl-C within %v of the last one: the debugger handled it instead of quitting", interruptWindow)
+	}
+	if !interrupt(start.Add(interruptWindow)) {
+		t.Errorf("Ctrl-C %v after the last one: the debugger did not handle it", interruptWindow)
+	}
+}
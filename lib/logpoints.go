@@ -0,0 +1,198 @@
+package godebug
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"strconv"
+	"strings"
+)
+
+// A logMessage is the message of a logpoint. Expressions in braces, as in
+// "id={id} state={s.state}", are replaced by their values when it is printed.
+// Doubled braces stand for themselves.
+type logMessage struct {
+	text  string // as written
+	parts []logPart
+}
+
+// A logPart is either literal text or an expression to print the value of.
+type logPart struct {
+	text string
+	expr ast.Expr // nil for literal text
+}
+
+func parseLogMessage(text string) (*logMessage, error) {
+	m := &logMessage{text: text}
+	var lit bytes.Buffer
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case (c == '{' || c == '}') && i+1 < len(text) && text[i+1] == c:
+			lit.WriteByte(c)
+			i++
+		case c == '}':
+			return nil, fmt.Errorf("The message %q has a } without a matching {. Write }} for a brace.", text)
+		case c == '{':
+			end := matchingBrace(text, i)
+			if end < 0 {
+				return nil, fmt.Errorf("The message %q has a { without a matching }. Write {{ for a brace.", text)
+			}
+			src := strings.TrimSpace(text[i+1 : end])
+			e, err := parser.ParseExpr(src)
+			if err != nil {
+				return nil, fmt.Errorf("Could not parse {%s} in the message %q: %v", src, text, err)
+			}
+			if lit.Len() > 0 {
+				m.parts = append(m.parts, logPart{text: lit.String()})
+				lit.Reset()
+			}
+			m.parts = append(m.parts, logPart{text: src, expr: e})
+			i = end
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	if lit.Len() > 0 {
+		m.parts = append(m.parts, logPart{text: lit.String()})
+	}
+	return m, nil
+}
+
+// matchingBrace returns the index of the } that closes the { at text[open],
+// or -1 if there is none. Braces may nest, as in a composite literal.
+func matchingBrace(text string, open int) int {
+	depth := 0
+	for i := open; i < len(text); i++ {
+		switch text[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// format returns m with the values of its expressions in s. An expression
+// that cannot be evaluated is replaced by the reason, in angle brackets.
+func (m *logMessage) format(s *Scope) string {
+	var buf bytes.Buffer
+	for _, p := range m.parts {
+		if p.expr == nil {
+			buf.WriteString(p.text)
+			continue
+		}
+		v, err := s.eval(p.expr)
+		switch {
+		case err != nil:
+			buf.WriteString("<" + err.Error() + ">")
+		case !v.IsValid():
+			buf.WriteString("nil")
+		default:
+			// String and Error methods may run instrumented code, which must not pause.
+			text, err := call(func() string { return fmt.Sprint(v.Interface()) })
+			if err != nil {
+				text = "<" + err.Error() + ">"
+			}
+			buf.WriteString(text)
+		}
+	}
+	return buf.String()
+}
+
+func (m *logMessage) String() string {
+	return strconv.Quote(m.text)
+}
+
+// printLog prints the message of a logpoint at loc.
+func printLog(loc location, s *Scope, m *logMessage) {
+	fmt.Printf("%s: %s\n", loc, m.format(s))
+}
+
+// Logpoint is the generated marker for a logpoint written in the source as
+// _ = "logpoint: message". It tells the next line to print message.
+func Logpoint(ctx *Context, message string) {
+	ctx.logMessage = message
+}
+
+// logSource prints the message that Logpoint gave the line c is at, if any.
+func logSource(c *Context, s *Scope, line int) {
+	if c.logMessage == "" {
+		return
+	}
+	text := c.logMessage
+	c.logMessage = ""
+	loc := location{s.file, line}
+	m, err := parseLogMessage(text)
+	if err != nil {
+		fmt.Printf("Could not print the logpoint at %s: %v\n", loc, err)
+		return
+	}
+	printLog(loc, s, m)
+}
+
+// splitLogArgs splits the arguments of the log command into the location or
+// function, the unquoted message and the rest.
+func splitLogArgs(args string) (where, message, rest string, err error) {
+	if !strings.HasPrefix(args, `"`) {
+		where, args = splitBreakpointArgs(args)
+	}
+	if !strings.HasPrefix(args, `"`) {
+		return "", "", "", fmt.Errorf(`Give a message in double quotes, as in "log file.go:42 \"x={x}\"".`)
+	}
+	message, rest, err = unquotePrefix(args)
+	return where, message, rest, err
+}
+
+// unquotePrefix unquotes the Go string literal at the start of s, and returns the rest of s.
+func unquotePrefix(s string) (value, rest string, err error) {
+	end := -1
+	switch {
+	case strings.HasPrefix(s, "`"):
+		if i := strings.Index(s[1:], "`"); i >= 0 {
+			end = i + 1
+		}
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s) && end < 0; i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return "", "", fmt.Errorf("The message %s is missing its closing quote.", s)
+	}
+	if value, err = strconv.Unquote(s[:end+1]); err != nil {
+		return "", "", fmt.Errorf("Could not parse the message %s: %v", s[:end+1], err)
+	}
+	return value, strings.TrimSpace(s[end+1:]), nil
+}
+
+// setLogpoint handles the command `log [[file:]line | func] "message" [if cond]`.
+func setLogpoint(scope *Scope, line int, args string) {
+	where, text, rest, err := splitLogArgs(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	b := &breakpoint{}
+	if b.message, err = parseLogMessage(text); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err = b.parseWhere(scope, line, where); err == nil {
+		err = b.parseCondition(rest, `log file.go:42 "x={x}" if x > 3`)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	addBreakpoint(b)
+}
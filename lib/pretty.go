@@ -0,0 +1,454 @@
+package godebug
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// The print settings limit how much of a value the debugger shows. They are
+// changed from the prompt with "set print". Zero means no limit.
+var (
+	printDepth    int32 = 8   // levels of nested structs, slices, arrays and maps
+	printElements int32 = 100 // elements of each slice, array or map
+	printStrings  int32 = 200 // bytes of each string
+)
+
+var printSettings = []struct {
+	name             string
+	limit            *int32
+	limited, unlimit string
+}{
+	{"depth", &printDepth, "Nested values are shown to a depth of %d.", "Nested values are shown to any depth."},
+	{"elements", &printElements, "Up to %d elements of each slice, array or map are shown.", "Every element of each slice, array or map is shown."},
+	{"strings", &printStrings, "Up to %d bytes of each string are shown.", "Every byte of each string is shown."},
+}
+
+// printWidth is the width values are kept within by putting their elements on separate lines.
+const printWidth = 80
+
+func formatValue(v value) string {
+	return formatValueAs(v, formatDefault)
+}
+
+func formatValueAs(v value, f printFormat) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	if f == formatRaw {
+		return fmt.Sprintf("%#v", accessible(v.Value).Interface())
+	}
+	p := &valuePrinter{
+		f:        f,
+		depth:    int(atomic.LoadInt32(&printDepth)),
+		elements: int(atomic.LoadInt32(&printElements)),
+		strings:  int(atomic.LoadInt32(&printStrings)),
+		visiting: make(visited),
+	}
+	var buf bytes.Buffer
+	p.format(v.Value, 0).layout(&buf, 0)
+	return buf.String()
+}
+
+// A pretty is a value laid out for printing. It is either text, or a composite
+// value with an opening like "main.T{", elements and a closing. A composite is
+// printed on one line if it fits, and with an element on each line otherwise.
+type pretty struct {
+	text      string
+	elems     []*pretty
+	close     string
+	composite bool
+
+	// pack is set for slices and arrays, whose elements are put
+	// several to a line when none of them are composite values.
+	pack bool
+
+	// typed is set if the text shows the type of the value,
+	// so that it does not need to be shown again.
+	typed bool
+}
+
+func leaf(text string, typed bool) *pretty {
+	return &pretty{text: text, typed: typed}
+}
+
+func (p *pretty) flat() string {
+	if !p.composite {
+		return p.text
+	}
+	elems := make([]string, len(p.elems))
+	for i, e := range p.elems {
+		elems[i] = e.flat()
+	}
+	return p.text + strings.Join(elems, ", ") + p.close
+}
+
+func (p *pretty) layout(buf *bytes.Buffer, indent int) {
+	flat := p.flat()
+	if !p.composite || len(p.elems) == 0 || 4*indent+len(flat) <= printWidth {
+		buf.WriteString(flat)
+		return
+	}
+	buf.WriteString(p.text + "\n")
+	if p.packable() {
+		p.layoutPacked(buf, indent+1)
+		buf.WriteString(strings.Repeat("    ", indent) + p.close)
+		return
+	}
+	for _, e := range p.elems {
+		buf.WriteString(strings.Repeat("    ", indent+1))
+		e.layout(buf, indent+1)
+		buf.WriteString(",\n")
+	}
+	buf.WriteString(strings.Repeat("    ", indent) + p.close)
+}
+
+func (p *pretty) packable() bool {
+	if !p.pack {
+		return false
+	}
+	for _, e := range p.elems {
+		if e.composite {
+			return false
+		}
+	}
+	return true
+}
+
+// layoutPacked writes the elements of p with as many on each line as fit.
+func (p *pretty) layoutPacked(buf *bytes.Buffer, indent int) {
+	line := ""
+	for _, e := range p.elems {
+		text := e.text + ","
+		if line != "" && 4*indent+len(line)+1+len(text) > printWidth {
+			buf.WriteString(strings.Repeat("    ", indent) + line + "\n")
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += text
+	}
+	buf.WriteString(strings.Repeat("    ", indent) + line + "\n")
+}
+
+// withType returns p, showing the type t if p does not already. Like Go
+// constants, strings, booleans and integers are read as string, bool and int
+// unless they show otherwise, so those types are not shown.
+func (p *pretty) withType(t reflect.Type) *pretty {
+	switch t {
+	case reflect.TypeOf(""), reflect.TypeOf(false), reflect.TypeOf(0):
+		return p
+	}
+	if !p.typed {
+		p.text = t.String() + "(" + p.text + ")"
+		p.typed = true
+	}
+	return p
+}
+
+// A valuePrinter formats a value within the limits of the print settings.
+type valuePrinter struct {
+	f                        printFormat
+	depth, elements, strings int
+
+	// visiting holds the pointers, maps and slices on the way from the
+	// value being printed to the current one, so that cycles can be detected.
+	visiting visited
+}
+
+// A visit identifies the pointer, map or slice being visited. Slices that
+// start at the same element are told apart by their length.
+type visit struct {
+	addr uintptr
+	typ  reflect.Type
+	len  int
+}
+
+func visitOf(v reflect.Value) visit {
+	k := visit{addr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		k.len = v.Len()
+	}
+	return k
+}
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// format formats v, which is depth levels into the value being printed.
+func (p *valuePrinter) format(v reflect.Value, depth int) *pretty {
+	if !v.IsValid() {
+		return leaf("nil", false)
+	}
+	v = accessible(v)
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return leaf("nil", false)
+		}
+		return p.format(v.Elem(), depth).withType(v.Elem().Type())
+	}
+	if s, ok := p.registered(v); ok {
+		return s
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return leaf(nilOf(t), true)
+		}
+	}
+	if s, ok := p.formatted(v); ok {
+		return s
+	}
+	if s, ok := p.special(v); ok {
+		return s
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if !p.visiting.enter(v) {
+			return leaf("("+t.String()+")(<cycle>)", true)
+		}
+		defer p.visiting.leave(v)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		e := p.format(v.Elem(), depth).withType(t.Elem())
+		e.text = "&" + e.text
+		return e
+	case reflect.Struct:
+		if p.tooDeep(depth) {
+			return leaf(t.String()+"{...}", true)
+		}
+		s := &pretty{text: t.String() + "{", close: "}", composite: true, typed: true}
+		v = addressable(v)
+		for i := 0; i < v.NumField(); i++ {
+			f := p.format(v.Field(i), depth+1)
+			f.text = t.Field(i).Name + ": " + f.text
+			s.elems = append(s.elems, f)
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		if p.tooDeep(depth) {
+			return leaf(t.String()+"{...}", true)
+		}
+		s := &pretty{text: t.String() + "{", close: "}", composite: true, typed: true, pack: true}
+		n := p.limit(v.Len())
+		for i := 0; i < n; i++ {
+			s.elems = append(s.elems, p.format(v.Index(i), depth+1))
+		}
+		if n < v.Len() {
+			s.elems = append(s.elems, leaf(fmt.Sprintf("... (%d more)", v.Len()-n), false))
+		}
+		return s
+	case reflect.Map:
+		if p.tooDeep(depth) {
+			return leaf(t.String()+"{...}", true)
+		}
+		s := &pretty{text: t.String() + "{", close: "}", composite: true, typed: true}
+		keys := v.MapKeys()
+		sortKeys(keys)
+		n := p.limit(len(keys))
+		for _, k := range keys[:n] {
+			e := p.format(v.MapIndex(k), depth+1)
+			e.text = p.format(k, depth+1).flat() + ": " + e.text
+			s.elems = append(s.elems, e)
+		}
+		if n < len(keys) {
+			s.elems = append(s.elems, leaf(fmt.Sprintf("... (%d more)", len(keys)-n), false))
+		}
+		return s
+	case reflect.String:
+		return leaf(p.quote(v.String()), false)
+	case reflect.Func:
+		return leaf(funcName(v), true)
+	case reflect.Chan:
+		return leaf(fmt.Sprintf("(%s)(len %d, cap %d)", t, v.Len(), v.Cap()), true)
+	case reflect.UnsafePointer:
+		return leaf(fmt.Sprintf("%s(%#x)", t, v.Pointer()), true)
+	case reflect.Uintptr:
+		return leaf(fmt.Sprintf("%#x", v.Uint()), false)
+	}
+	return leaf(fmt.Sprint(v.Interface()), false)
+}
+
+// special formats the values that have a more readable form than their contents.
+func (p *valuePrinter) special(v reflect.Value) (*pretty, bool) {
+	switch v.Type() {
+	case timeType:
+		return leaf(v.Interface().(time.Time).Round(0).String(), false), true
+	case durationType:
+		return leaf(v.Interface().(time.Duration).String(), false), true
+	}
+	var text string
+	var err error
+	switch {
+	case v.Type().Implements(errorType):
+		text, err = call(func() string { return v.Interface().(error).Error() })
+	case v.Type().Implements(stringerType):
+		text, err = call(func() string { return v.Interface().(fmt.Stringer).String() })
+	default:
+		return nil, false
+	}
+	if err != nil {
+		// Fall back to showing what is in the value.
+		return nil, false
+	}
+	return leaf(v.Type().String()+"("+p.quote(text)+")", true), true
+}
+
+// call returns the result of f, or an error if it panics. f may run
+// instrumented code, which must not pause the debugger that is calling it.
+func call(f func() string) (s string, err error) {
+	if g, ok := context.GetValue(goroutineKey); ok {
+		g.(*goroutine).evaluating++
+		defer func() { g.(*goroutine).evaluating-- }()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("panicked")
+		}
+	}()
+	return f(), nil
+}
+
+func (p *valuePrinter) tooDeep(depth int) bool {
+	return p.depth > 0 && depth >= p.depth
+}
+
+// limit returns how many of n elements to show.
+func (p *valuePrinter) limit(n int) int {
+	if p.elements > 0 && n > p.elements {
+		return p.elements
+	}
+	return n
+}
+
+// quote quotes s, leaving off the end of it if it is too long.
+func (p *valuePrinter) quote(s string) string {
+	if p.strings <= 0 || len(s) <= p.strings {
+		return strconv.Quote(s)
+	}
+	n := p.strings
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s... (%d bytes)", strconv.Quote(s[:n]), len(s))
+}
+
+func nilOf(t reflect.Type) string {
+	return "(" + t.String() + ")(nil)"
+}
+
+// funcName returns the name of the function v and where it is defined. The
+// lines of instrumented functions are left out, since they are the lines of
+// the generated code.
+func funcName(v reflect.Value) string {
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "(" + v.Type().String() + ")(unknown)"
+	}
+	file, line := f.FileLine(f.Entry())
+	file = filepath.ToSlash(file)
+	if name, ok := instrumentedFile(file); ok {
+		return f.Name() + " in " + shortName(name)
+	}
+	return f.Name() + " at " + filepath.Base(file) + ":" + strconv.Itoa(line)
+}
+
+// instrumentedFile returns the path the runtime knows the instrumented
+// file at the absolute path file by, if it is one.
+func instrumentedFile(file string) (string, bool) {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	var bySuffix, byBase []string
+	for name := range files {
+		switch {
+		case strings.HasSuffix(file, "/"+name):
+			bySuffix = append(bySuffix, name)
+		case path.Base(name) == path.Base(file):
+			// The files given to godebug run are not in a
+			// directory named after their import path.
+			byBase = append(byBase, name)
+		}
+	}
+	if len(bySuffix) == 1 {
+		return bySuffix[0], true
+	}
+	if len(bySuffix) == 0 && len(byBase) == 1 {
+		return byBase[0], true
+	}
+	return "", false
+}
+
+// sortKeys sorts map keys, so that maps are printed the same way each time.
+func sortKeys(keys []reflect.Value) {
+	sort.Sort(byKey(keys))
+}
+
+type byKey []reflect.Value
+
+func (s byKey) Len() int      { return len(s) }
+func (s byKey) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s byKey) Less(i, j int) bool {
+	x, y := accessible(s[i]), accessible(s[j])
+	switch {
+	case x.Kind() != y.Kind():
+		return x.Kind() < y.Kind()
+	case isInt(x.Kind()):
+		return x.Int() < y.Int()
+	case isUint(x.Kind()):
+		return x.Uint() < y.Uint()
+	case isFloat(x.Kind()):
+		return x.Float() < y.Float()
+	case x.Kind() == reflect.String:
+		return x.String() < y.String()
+	}
+	return fmt.Sprint(x.Interface()) < fmt.Sprint(y.Interface())
+}
+
+// setPrintLimit handles the command "set print [depth|elements|strings] [n]".
+// Without a number, it shows the limit.
+func setPrintLimit(args string) {
+	name, n := splitCommand(args)
+	for _, s := range printSettings {
+		if name != "" && name != s.name {
+			continue
+		}
+		if n != "" {
+			limit, err := strconv.Atoi(n)
+			if err != nil || limit < 0 {
+				fmt.Printf("%q is not a valid limit.\n", n)
+				return
+			}
+			atomic.StoreInt32(s.limit, int32(limit))
+		}
+		if limit := atomic.LoadInt32(s.limit); limit == 0 {
+			fmt.Println(s.unlimit)
+		} else {
+			fmt.Printf(s.limited+"\n", limit)
+		}
+		if name != "" {
+			return
+		}
+	}
+	if name != "" {
+		fmt.Printf("There is no print setting called %q. The settings are depth, elements and strings.\n", name)
+	}
+}
@@ -0,0 +1,120 @@
+package godebug
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// chain returns s and its ancestors, outermost first. The file-level
+// scope is left out, since nothing is ever declared in it.
+func (s *Scope) chain() []*Scope {
+	var scopes []*Scope
+	for scope := s; scope != nil && scope.parent != nil; scope = scope.parent {
+		scopes = append([]*Scope{scope}, scopes...)
+	}
+	return scopes
+}
+
+// binding returns the value of the variable or constant called name
+// declared in s itself, and whether it is a constant.
+func (s *Scope) binding(name string) (v value, isConst bool) {
+	if i, ok := s.vars[name]; ok {
+		return value{Value: reflect.ValueOf(i).Elem()}, false
+	}
+	return value{Value: reflect.ValueOf(s.consts[name])}, true
+}
+
+// shadows reports whether name is declared in a scope between s and outer, excluding outer.
+func (s *Scope) shadows(outer *Scope, name string) bool {
+	for scope := s; scope != outer; scope = scope.parent {
+		if _, ok := scope.vars[name]; ok {
+			return true
+		}
+		if _, ok := scope.consts[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func printBinding(scope *Scope, name, suffix string) {
+	v, isConst := scope.binding(name)
+	if isConst {
+		suffix += " (constant)"
+	}
+	fmt.Printf("%s = %s%s\n", name, formatValue(v), suffix)
+}
+
+// printLocals handles the command "locals".
+func printLocals(s *Scope) {
+	n := 0
+	for _, scope := range s.chain() {
+		for _, name := range scope.names {
+			if !s.shadows(scope, name) {
+				printBinding(scope, name, "")
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		fmt.Println("There are no variables in scope.")
+	}
+}
+
+// printArgs handles the command "args".
+func printArgs(s *Scope) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if scope.args == nil {
+			continue
+		}
+		if len(scope.args) == 0 {
+			break
+		}
+		for _, name := range scope.args {
+			printBinding(scope, name, "")
+		}
+		return
+	}
+	fmt.Println("The function has no arguments.")
+}
+
+// printScopes handles the command "info scopes".
+func printScopes(s *Scope) {
+	scopes := s.chain()
+	if len(scopes) == 0 {
+		fmt.Println("There are no variables in scope.")
+		return
+	}
+	for i := len(scopes) - 1; i >= 0; i-- {
+		scope := scopes[i]
+		header := fmt.Sprintf("Scope %d", len(scopes)-1-i)
+		if scope.args != nil {
+			// Scopes further out belong to the function enclosing a function literal.
+			header += ", the outermost scope of a function"
+		}
+		fmt.Println(header + ":")
+		if len(scope.names) == 0 {
+			fmt.Println("    nothing declared")
+		}
+		for _, name := range scope.names {
+			suffix := ""
+			if isArg(scope, name) {
+				suffix += " (argument)"
+			}
+			if s.shadows(scope, name) {
+				suffix += " (shadowed)"
+			}
+			fmt.Print("    ")
+			printBinding(scope, name, suffix)
+		}
+	}
+}
+
+func isArg(s *Scope, name string) bool {
+	for _, arg := range s.args {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
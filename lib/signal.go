@@ -0,0 +1,45 @@
+// +build !js
+
+package godebug
+
+import (
+	"os"
+	"os/signal"
+	"time"
+)
+
+// launchedEnvVar is set by the godebug command when it runs the program. A program
+// built with godebug and run some other way is left to handle Ctrl-C itself.
+const launchedEnvVar = "GODEBUG_LAUNCHED"
+
+// handOnDelay is how long the debugger stops listening for interrupts after handing
+// one on, so that the signal reaches the program before the debugger listens again.
+const handOnDelay = 100 * time.Millisecond
+
+func init() {
+	if os.Getenv(launchedEnvVar) == "" {
+		return
+	}
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		for range interrupts {
+			if !interrupt(time.Now()) {
+				handOn(interrupts)
+			}
+		}
+	}()
+}
+
+// handOn sends an interrupt again to the handlers the program registered, if any,
+// or else to the default one, which ends the program the way Ctrl-C would have
+// without the debugger. Then interrupts is notified of the next one.
+func handOn(interrupts chan os.Signal) {
+	signal.Stop(interrupts)
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil || p.Signal(os.Interrupt) != nil {
+		os.Exit(1)
+	}
+	time.Sleep(handOnDelay)
+	signal.Notify(interrupts, os.Interrupt)
+}
@@ -0,0 +1,369 @@
+package godebug
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// A goroutine is the debugger's record of a goroutine that has run instrumented
+// code. It is stored in goroutine-local storage under goroutineKey.
+type goroutine struct {
+	id uint32
+
+	// frames is the stack of instrumented functions the goroutine is in,
+	// innermost last. Calls through uninstrumented code do not appear.
+	// It and the scope and line of each frame are only changed by the
+	// goroutine itself, while holding mu, and the debugger may read them
+	// from another goroutine while holding mu.
+	mu     sync.Mutex
+	frames []*frame
+
+	// caughtPanic is set when the debugger has caught a panic in the
+	// goroutine, and cleared once the goroutine is no longer panicking.
+	caughtPanic bool
+
+	// evaluating counts the calls the debugger is making from the goroutine,
+	// as when it prints a value with its String method. The instrumented code
+	// they run does not pause or catch anything.
+	evaluating int
+}
+
+// goroutines holds every goroutine that is running instrumented code, by id.
+var (
+	goroutinesMu sync.Mutex
+	goroutines   = make(map[uint32]*goroutine)
+)
+
+func newGoroutine(id uint32) *goroutine {
+	g := &goroutine{id: id}
+	goroutinesMu.Lock()
+	goroutines[id] = g
+	goroutinesMu.Unlock()
+	return g
+}
+
+// exit is called when g returns from its outermost instrumented function.
+func (g *goroutine) exit() {
+	goroutinesMu.Lock()
+	delete(goroutines, g.id)
+	goroutinesMu.Unlock()
+	if atomic.LoadUint32(&currentGoroutine) == g.id {
+		// Nothing else would ever pause, so let any goroutine that
+		// reaches a breakpoint become the one that is followed.
+		atomic.StoreInt32(&currentState, run)
+	}
+}
+
+// stack returns a copy of g's frames.
+func (g *goroutine) stack() []*frame {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]*frame(nil), g.frames...)
+}
+
+// A frame is an active call of an instrumented function.
+type frame struct {
+	fn   string // qualified name, like main.(*T).Method or main.main.func1
+	file string
+
+	// scope and line are the arguments of the most recent Line call in
+	// the frame. scope is nil if no line in the frame has run yet.
+	// They are set with goroutine.at.
+	scope *Scope
+	line  int
+
+	// returnLine is the line that was running when the deferred functions
+	// of the frame started to run, or 0 if they have not.
+	returnLine int
+
+	// paused is a snapshot of the variables in scope when the program last
+	// resumed after pausing in the frame, or nil if it has not paused there.
+	paused map[varKey]reflect.Value
+}
+
+// exitLine returns the line the function returned or panicked at.
+func (f *frame) exitLine() int {
+	if f.returnLine != 0 {
+		return f.returnLine
+	}
+	return f.line
+}
+
+// at records that f, one of g's frames, is at line in s.
+func (g *goroutine) at(f *frame, s *Scope, line int) {
+	g.mu.Lock()
+	f.scope, f.line = s, line
+	g.mu.Unlock()
+}
+
+// A frameState is a frame with the scope and line it was at when the debugger
+// looked at it. The frame's goroutine may have moved on since.
+type frameState struct {
+	*frame
+	scope *Scope
+	line  int
+}
+
+// states returns the state of each of g's frames, innermost last.
+func (g *goroutine) states() []frameState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	states := make([]frameState, len(g.frames))
+	for i, f := range g.frames {
+		states[i] = frameState{f, f.scope, f.line}
+	}
+	return states
+}
+
+func (f frameState) String() string {
+	if f.scope == nil {
+		return f.fn + " in " + shortName(f.file)
+	}
+	return f.fn + " at " + location{f.scope.file, f.line}.String()
+}
+
+func (g *goroutine) push(fn, file string) *frame {
+	f := &frame{fn: fn, file: file}
+	g.mu.Lock()
+	g.frames = append(g.frames, f)
+	g.mu.Unlock()
+	return f
+}
+
+// pop removes f and any frames above it from the stack.
+func (g *goroutine) pop(f *frame) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i := len(g.frames) - 1; i >= 0; i-- {
+		if g.frames[i] == f {
+			g.frames[i] = nil
+			g.frames = g.frames[:i]
+			return
+		}
+	}
+}
+
+// A stackView is the debugger's view of a paused goroutine's stack.
+// Frame 0 is the innermost frame, where the goroutine is paused.
+type stackView struct {
+	frames   []frameState
+	selected int
+}
+
+func newStackView(g *goroutine) *stackView {
+	states := g.states()
+	frames := make([]frameState, len(states))
+	for i, f := range states {
+		frames[len(frames)-1-i] = f
+	}
+	return &stackView{frames: frames}
+}
+
+// backtrace handles the command "bt".
+func (v *stackView) backtrace() {
+	for i, f := range v.frames {
+		marker := " "
+		if i == v.selected {
+			marker = "*"
+		}
+		fmt.Printf("%s #%d %s\n", marker, i, f)
+	}
+}
+
+// move handles the commands "up" and "down". It reports whether the selected frame changed.
+func (v *stackView) move(delta int) bool {
+	n := v.selected + delta
+	switch {
+	case n >= len(v.frames):
+		fmt.Println("Already at the outermost frame.")
+		return false
+	case n < 0:
+		fmt.Println("Already at the innermost frame.")
+		return false
+	}
+	return v.selectFrame(n)
+}
+
+// selectFrameCommand handles the command "frame N".
+func (v *stackView) selectFrameCommand(args string) bool {
+	n, err := strconv.Atoi(strings.TrimPrefix(args, "#"))
+	if err != nil {
+		fmt.Printf("%q is not a frame number. Frame numbers are shown by bt.\n", args)
+		return false
+	}
+	if n < 0 || n >= len(v.frames) {
+		fmt.Printf("There is no frame %d.\n", n)
+		return false
+	}
+	return v.selectFrame(n)
+}
+
+func (v *stackView) selectFrame(n int) bool {
+	f := v.frames[n]
+	if f.scope == nil {
+		fmt.Printf("No line in frame #%d has run yet.\n", n)
+		return false
+	}
+	v.selected = n
+	fmt.Printf("#%d %s\n", n, f)
+	fmt.Println("-> " + strings.TrimSpace(f.scope.fileText[f.line-1]))
+	return true
+}
+
+// current returns the scope and line of the selected frame.
+func (v *stackView) current() (*Scope, int) {
+	f := v.frames[v.selected]
+	return f.scope, f.line
+}
+
+// finishing is the frame that the finish command is waiting to return from.
+var finishing *frame
+
+// finish handles the command "finish". It reports whether the program should resume.
+func (v *stackView) finish() bool {
+	f := v.frames[v.selected]
+	if f.fn == "main.main" {
+		fmt.Println(`"finish" is not meaningful in main.main, which never returns.`)
+		return false
+	}
+	finishing = f.frame
+	currentState = finish
+	return true
+}
+
+// finished is called by ExitFunc when the frame the finish command
+// was waiting for returns. It pauses in the caller, if it is instrumented.
+// If the frame is panicking instead, it returns nothing, and the debugger
+// pauses at the next line that runs, such as in a deferred function.
+func finished(ctx *Context, results []interface{}, panicked bool) {
+	finishing = nil
+	if panicked {
+		fmt.Printf("%s panicked before it returned.\n", ctx.frame.fn)
+		currentState = step
+		return
+	}
+	vals := make([]string, len(results))
+	for i, r := range results {
+		vals[i] = formatValue(value{Value: reflect.ValueOf(r).Elem()})
+	}
+	if len(vals) == 0 {
+		fmt.Printf("%s returned.\n", ctx.frame.fn)
+	} else {
+		fmt.Printf("%s returned %s\n", ctx.frame.fn, strings.Join(vals, ", "))
+	}
+	stack := ctx.g.stack()
+	if len(stack) == 0 {
+		// The caller is not instrumented. Pause at the next line that is.
+		currentState = step
+		return
+	}
+	caller := stack[len(stack)-1]
+	debuggerDepth = currentDepth
+	justLeft = false
+	fmt.Println("-> " + strings.TrimSpace(caller.scope.fileText[caller.line-1]))
+	waitForInput(ctx, caller.scope, caller.line)
+}
+
+// listGoroutines handles the command "goroutines".
+func listGoroutines() {
+	goroutinesMu.Lock()
+	var ids []int
+	for id := range goroutines {
+		ids = append(ids, int(id))
+	}
+	goroutinesMu.Unlock()
+	sort.Ints(ids)
+	for _, id := range ids {
+		g := lookupGoroutine(uint32(id))
+		if g == nil {
+			continue
+		}
+		marker := " "
+		if g.id == atomic.LoadUint32(&currentGoroutine) {
+			marker = "*"
+		}
+		where := "not in an instrumented function"
+		if f, ok := g.lastFrame(); ok {
+			where = f.String()
+		}
+		fmt.Printf("%s Goroutine %d: %s\n", marker, id, where)
+	}
+}
+
+func lookupGoroutine(id uint32) *goroutine {
+	goroutinesMu.Lock()
+	defer goroutinesMu.Unlock()
+	return goroutines[id]
+}
+
+// lastFrame returns the state of the innermost of g's frames that has run a line.
+// It reports false if there is none.
+func (g *goroutine) lastFrame() (frameState, bool) {
+	states := g.states()
+	for i := len(states) - 1; i >= 0; i-- {
+		if states[i].scope != nil {
+			return states[i], true
+		}
+	}
+	return frameState{}, false
+}
+
+// switchGoroutine handles the command "goroutine N". Stepping continues in the
+// goroutine it returns, which is nil if the command failed.
+func switchGoroutine(args string) *goroutine {
+	if args == "" {
+		fmt.Printf("The debugger is following goroutine %d.\n", atomic.LoadUint32(&currentGoroutine))
+		return nil
+	}
+	id, err := strconv.ParseUint(args, 10, 32)
+	if err != nil {
+		fmt.Printf("%q is not a goroutine number. Goroutine numbers are shown by goroutines.\n", args)
+		return nil
+	}
+	g := lookupGoroutine(uint32(id))
+	if g == nil {
+		fmt.Printf("There is no goroutine %d running instrumented code.\n", id)
+		return nil
+	}
+	if _, ok := g.lastFrame(); !ok {
+		fmt.Printf("Goroutine %d has not run any instrumented lines yet.\n", id)
+		return nil
+	}
+	atomic.StoreUint32(&currentGoroutine, g.id)
+	// Depths are only tracked for the goroutine being followed, so start afresh.
+	currentDepth, debuggerDepth, justLeft = 0, 0, false
+	fmt.Printf("Switched to goroutine %d.\n", id)
+	return g
+}
+
+// missed holds reports of breakpoints that goroutines reached, and errors they
+// returned, while the debugger was following a different goroutine. They are
+// shown at the next pause.
+var (
+	missedMu sync.Mutex
+	missed   []string
+)
+
+func recordMissed(c *Context, loc location) {
+	addMissed(fmt.Sprintf("Goroutine %d reached the breakpoint at %s while goroutine %d was being debugged.", c.goroutine, loc, atomic.LoadUint32(&currentGoroutine)))
+}
+
+func addMissed(report string) {
+	missedMu.Lock()
+	missed = append(missed, report)
+	missedMu.Unlock()
+}
+
+func reportMissed() {
+	missedMu.Lock()
+	defer missedMu.Unlock()
+	for _, m := range missed {
+		fmt.Println(m)
+	}
+	missed = nil
+}
@@ -0,0 +1,34 @@
+package godebug
+
+import "testing"
+
+// TestStackViewWhileRunning looks at a goroutine's stack while the goroutine
+// moves from line to line, as "goroutines" and "goroutine N" do. Run it with
+// -race to check that the debugger only sees whole lines.
+func TestStackViewWhileRunning(t *testing.T) {
+	g := &goroutine{id: 1}
+	f := g.push("main.work", "main/work.go")
+	scopes := []*Scope{{file: "main/work.go"}, {file: "main/work.go"}}
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			g.at(f, scopes[i%2], 10+i%2)
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		v := newStackView(g)
+		s, line := v.frames[0].scope, v.frames[0].line
+		if s != nil && (s == scopes[0]) != (line == 10) {
+			t.Fatalf("stack view of a running goroutine: line %d with the scope of the other line", line)
+		}
+		if lf, ok := g.lastFrame(); ok && lf.frame != f {
+			t.Fatalf("lastFrame: got %s, want main.work", lf)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package godebug
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// A namedType is a package-level named type declared in an instrumented package.
+type namedType struct {
+	pkg  string // the package name
+	decl string // as the ptype command shows it
+}
+
+var (
+	namedTypesMu sync.Mutex
+	namedTypes   = make(map[string][]namedType) // by type name
+)
+
+// DeclareTypes records the named types declared in a file of the package called
+// pkg, for the ptype command. namedecl is a list of name, declaration pairs.
+// It returns true so that generated code can call it in a package-level
+// variable declaration.
+func DeclareTypes(pkg string, namedecl ...string) bool {
+	if len(namedecl)%2 != 0 {
+		panic("programming error: called DeclareTypes with odd number of arguments")
+	}
+	namedTypesMu.Lock()
+	defer namedTypesMu.Unlock()
+	for i := 0; i < len(namedecl); i += 2 {
+		name := namedecl[i]
+		namedTypes[name] = append(namedTypes[name], namedType{pkg: pkg, decl: namedecl[i+1]})
+	}
+	return true
+}
+
+// declaredType returns the type that the variable or constant called name
+// was declared with in s or its ancestors, if it is known.
+func (s *Scope) declaredType(name string) (string, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		_, isVar := scope.vars[name]
+		_, isConst := scope.consts[name]
+		if isVar || isConst {
+			t := scope.types[name]
+			return t, t != ""
+		}
+	}
+	return "", false
+}
+
+// whatis handles the command "whatis <expr>". It shows the type of the expression
+// as it is declared, and for interfaces the type of the value they hold.
+func whatis(s *Scope, expr string) {
+	v, err := s.evalString(expr)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	declared := typeString(v)
+	if e, err := parser.ParseExpr(expr); err == nil {
+		if ident, ok := e.(*ast.Ident); ok {
+			if t, ok := s.declaredType(ident.Name); ok {
+				declared = t
+			}
+		}
+	}
+	switch {
+	case !v.IsValid() || v.Kind() != reflect.Interface:
+		fmt.Printf("%s: %s\n", expr, declared)
+	case v.IsNil():
+		fmt.Printf("%s: %s (holding nil)\n", expr, declared)
+	default:
+		fmt.Printf("%s: %s (holding %s)\n", expr, declared, v.Elem().Type())
+	}
+}
+
+// ptype handles the command "ptype <type>". It shows the fields and methods of
+// a named type declared in an instrumented package, which may be qualified with
+// the name of its package. Given an expression instead, it shows its type.
+func ptype(s *Scope, args string) {
+	name := strings.TrimLeft(args, "*")
+	pkg := ""
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		pkg, name = name[:i], name[i+1:]
+	}
+	found := lookupNamedTypes(pkg, name)
+	if len(found) == 0 {
+		if v, err := s.evalString(args); err == nil && v.IsValid() {
+			t := v.Type()
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Name() != "" {
+				pkg = strings.TrimSuffix(t.String(), "."+t.Name())
+				found = lookupNamedTypes(pkg, t.Name())
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		fmt.Printf("There is no type %s in the instrumented packages.\n", args)
+	case 1:
+		fmt.Println(found[0].decl)
+	default:
+		for i, t := range found {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("In package %s:\n%s\n", t.pkg, t.decl)
+		}
+	}
+}
+
+// lookupNamedTypes returns the named types called name, in the package called pkg
+// if it is not empty.
+func lookupNamedTypes(pkg, name string) []namedType {
+	namedTypesMu.Lock()
+	defer namedTypesMu.Unlock()
+	var found []namedType
+	for _, t := range namedTypes[name] {
+		if pkg == "" || t.pkg == pkg {
+			found = append(found, t)
+		}
+	}
+	return found
+}
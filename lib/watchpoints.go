@@ -0,0 +1,375 @@
+package godebug
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// A watchpoint is set from the prompt with the watch command. The debugger
+// pauses whenever the value of its expression changes.
+type watchpoint struct {
+	id    int
+	expr  ast.Expr
+	text  string
+	scope *Scope // the scope the expression is evaluated in
+
+	// old is a snapshot of the value when it was last checked. If the
+	// expression could not be evaluated, err is the reason.
+	old reflect.Value
+	err error
+}
+
+var (
+	watchpointsMu  sync.Mutex
+	watchpoints    []*watchpoint
+	lastWatchpoint int
+
+	// watchpointCount mirrors len(watchpoints) so that Line
+	// does not need to take a lock when there are none.
+	watchpointCount int32
+)
+
+// check evaluates w and reports whether its value differs from the last time it was checked.
+func (w *watchpoint) check() (changed bool, old string) {
+	v, err := w.scope.eval(w.expr)
+	switch {
+	case err != nil && w.err != nil:
+		changed = err.Error() != w.err.Error()
+	case err != nil || w.err != nil:
+		changed = true
+	default:
+		changed = !sameValue(w.old, v.Value)
+	}
+	if changed {
+		old = w.format()
+		w.old, w.err = snapshot(v.Value), err
+	}
+	return changed, old
+}
+
+func (w *watchpoint) format() string {
+	if w.err != nil {
+		return "<" + w.err.Error() + ">"
+	}
+	return formatValue(value{Value: w.old})
+}
+
+// hitWatchpoint reports whether the debugger should pause because the value of a
+// watched expression changed since the last line c ran. It must be called before
+// the current line is recorded in c's frame. Like hitBreakpoint, it is consulted
+// even while the debugger is in state run.
+func hitWatchpoint(c *Context) bool {
+	if atomic.LoadInt32(&watchpointCount) == 0 {
+		return false
+	}
+	watchpointsMu.Lock()
+	defer watchpointsMu.Unlock()
+	var changes []string
+	for _, w := range watchpoints {
+		if changed, old := w.check(); changed {
+			changes = append(changes, fmt.Sprintf("Watchpoint %d: %s\nOld value: %s\nNew value: %s\n", w.id, w.text, old, w.format()))
+		}
+	}
+	if len(changes) == 0 {
+		return false
+	}
+	fmt.Print(strings.Join(changes, ""))
+	if f := changedBy(c); f != nil {
+		fmt.Printf("Changed by %s: %s\n", location{f.scope.file, f.line}, strings.TrimSpace(f.scope.fileText[f.line-1]))
+	}
+	if atomic.CompareAndSwapInt32(&currentState, run, step) {
+		atomic.StoreUint32(&currentGoroutine, c.goroutine)
+		return true
+	}
+	if atomic.LoadUint32(&currentGoroutine) != c.goroutine {
+		fmt.Printf("Not pausing, because the change was made by goroutine %d rather than the one being debugged.\n", c.goroutine)
+		return false
+	}
+	return true
+}
+
+// changedBy returns the frame whose most recent line is the one that ran
+// before the current line, or nil if there is none.
+func changedBy(c *Context) *frame {
+	if c.frame.scope != nil {
+		return c.frame
+	}
+	// No line in the current frame has run yet, so the change
+	// happened in the caller while it was calling this function.
+	frames := c.g.stack()
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i] != c.frame && frames[i].scope != nil {
+			return frames[i]
+		}
+	}
+	return nil
+}
+
+// setWatchpoint handles the command "watch <expr>".
+func setWatchpoint(scope *Scope, args string) {
+	if args == "" {
+		fmt.Println(`Give an expression to watch, as in "watch x" or "watch cfg.Timeout".`)
+		return
+	}
+	e, err := parser.ParseExpr(args)
+	if err != nil {
+		fmt.Printf("Could not parse %q: %v\n", args, err)
+		return
+	}
+	v, err := scope.eval(e)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	watchpointsMu.Lock()
+	defer watchpointsMu.Unlock()
+	lastWatchpoint++
+	w := &watchpoint{id: lastWatchpoint, expr: e, text: args, scope: scope, old: snapshot(v.Value)}
+	watchpoints = append(watchpoints, w)
+	atomic.StoreInt32(&watchpointCount, int32(len(watchpoints)))
+	fmt.Printf("Watchpoint %d: %s = %s\n", w.id, w.text, w.format())
+}
+
+// deleteWatchpoints handles the command "unwatch [n]".
+func deleteWatchpoints(args string) {
+	watchpointsMu.Lock()
+	defer watchpointsMu.Unlock()
+	defer func() {
+		atomic.StoreInt32(&watchpointCount, int32(len(watchpoints)))
+	}()
+	if args == "" {
+		watchpoints = nil
+		fmt.Println("Deleted all watchpoints.")
+		return
+	}
+	id, err := strconv.Atoi(args)
+	if err != nil {
+		fmt.Printf("%q is not a watchpoint number.\n", args)
+		return
+	}
+	for i, w := range watchpoints {
+		if w.id == id {
+			watchpoints = append(watchpoints[:i], watchpoints[i+1:]...)
+			fmt.Printf("Deleted watchpoint %d: %s\n", w.id, w.text)
+			return
+		}
+	}
+	fmt.Printf("There is no watchpoint %d.\n", id)
+}
+
+// refreshWatchpoints records the current value of every watchpoint, so that
+// changes made from the prompt with the set command do not trigger them.
+func refreshWatchpoints() {
+	watchpointsMu.Lock()
+	defer watchpointsMu.Unlock()
+	for _, w := range watchpoints {
+		w.check()
+	}
+}
+
+// A visited set holds the pointers, maps and slices that the printer or
+// sameValue is in the middle of. sameValue compares pointers rather than
+// following them, so for it only maps and slices can lead back to
+// themselves, through an interface value.
+type visited map[visit]bool
+
+// enter records that v is being visited. It reports false, recording
+// nothing, if v is already being visited because it contains itself.
+func (seen visited) enter(v reflect.Value) bool {
+	k := visitOf(v)
+	if seen[k] {
+		return false
+	}
+	seen[k] = true
+	return true
+}
+
+// leave records that v is no longer being visited.
+func (seen visited) leave(v reflect.Value) {
+	delete(seen, visitOf(v))
+}
+
+// snapshot returns a copy of v that will not change when the program changes v.
+// Pointers are copied, but not the values they point to, so that a watchpoint on
+// a pointer is triggered when the pointer changes, as with == in Go.
+func snapshot(v reflect.Value) reflect.Value {
+	return (&snapshotter{copies: make(map[visit]reflect.Value)}).copy(v)
+}
+
+// snapshotAtMost is like snapshot, but gives up and reports false if v has more
+// than n elements, fields and map entries in all.
+func snapshotAtMost(v reflect.Value, n int) (reflect.Value, bool) {
+	s := &snapshotter{copies: make(map[visit]reflect.Value), limited: true, left: n}
+	c := s.copy(v)
+	return c, s.left >= 0
+}
+
+// A snapshotter copies values for snapshot. If it is limited, it stops copying
+// once it has copied more than left elements, fields and map entries.
+type snapshotter struct {
+	// copies holds the copy of each map and slice copied so far, so that one
+	// that is in a value twice, or contains itself, is only copied once.
+	copies  map[visit]reflect.Value
+	limited bool
+	left    int
+}
+
+// spend reports whether the n elements, fields or map entries of a value
+// can be copied, and counts them if so.
+func (s *snapshotter) spend(n int) bool {
+	if !s.limited {
+		return true
+	}
+	s.left -= n
+	return s.left >= 0
+}
+
+func (s *snapshotter) copy(v reflect.Value) reflect.Value {
+	if !v.IsValid() {
+		return v
+	}
+	v = accessible(v)
+	n := 0
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		if c, ok := s.copies[visitOf(v)]; ok {
+			return c
+		}
+		n = v.Len()
+	case reflect.Array:
+		n = v.Len()
+	case reflect.Struct:
+		n = v.NumField()
+	}
+	if !s.spend(n) {
+		return reflect.Zero(v.Type())
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		s.copies[visitOf(v)] = c
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(s.copy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		c := reflect.MakeMap(v.Type())
+		s.copies[visitOf(v)] = c
+		for _, k := range v.MapKeys() {
+			c.SetMapIndex(k, s.copy(v.MapIndex(k)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(s.copy(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		v = addressable(v)
+		for i := 0; i < v.NumField(); i++ {
+			accessible(c.Field(i)).Set(s.copy(v.Field(i)))
+		}
+		return c
+	case reflect.Interface:
+		c := reflect.New(v.Type()).Elem()
+		if !v.IsNil() {
+			c.Set(s.copy(v.Elem()))
+		}
+		return c
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// sameValue reports whether x and y are equal, comparing pointers,
+// channels and functions by identity and everything else by value.
+// Unlike with == in Go, a NaN is the same as itself.
+func sameValue(x, y reflect.Value) bool {
+	return visited{}.sameValue(x, y)
+}
+
+func (seen visited) sameValue(x, y reflect.Value) bool {
+	if !x.IsValid() || !y.IsValid() {
+		return x.IsValid() == y.IsValid()
+	}
+	if x.Type() != y.Type() {
+		return false
+	}
+	x, y = accessible(x), accessible(y)
+	switch x.Kind() {
+	case reflect.Ptr, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return x.Pointer() == y.Pointer()
+	case reflect.Float32, reflect.Float64:
+		return sameFloat(x.Float(), y.Float())
+	case reflect.Complex64, reflect.Complex128:
+		cx, cy := x.Complex(), y.Complex()
+		return sameFloat(real(cx), real(cy)) && sameFloat(imag(cx), imag(cy))
+	case reflect.Slice:
+		if x.IsNil() != y.IsNil() || x.Len() != y.Len() {
+			return false
+		}
+		if x.IsNil() || !seen.enter(x) {
+			// Where x contains itself, the rest of it is compared further up.
+			return true
+		}
+		defer seen.leave(x)
+		for i := 0; i < x.Len(); i++ {
+			if !seen.sameValue(x.Index(i), y.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		for i := 0; i < x.Len(); i++ {
+			if !seen.sameValue(x.Index(i), y.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if x.IsNil() != y.IsNil() || x.Len() != y.Len() {
+			return false
+		}
+		if x.IsNil() || !seen.enter(x) {
+			return true
+		}
+		defer seen.leave(x)
+		for _, k := range x.MapKeys() {
+			if !seen.sameValue(x.MapIndex(k), y.MapIndex(k)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		x, y = addressable(x), addressable(y)
+		for i := 0; i < x.NumField(); i++ {
+			if !seen.sameValue(x.Field(i), y.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface:
+		if x.IsNil() || y.IsNil() {
+			return x.IsNil() == y.IsNil()
+		}
+		return seen.sameValue(x.Elem(), y.Elem())
+	}
+	return x.Interface() == y.Interface()
+}
+
+// sameFloat reports whether x and y are equal, or both NaN.
+func sameFloat(x, y float64) bool {
+	return x == y || x != x && y != y
+}
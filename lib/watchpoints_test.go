@@ -0,0 +1,57 @@
+package godebug
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotCycle(t *testing.T) {
+	m := map[string]interface{}{"n": 1}
+	m["self"] = m
+	s := []interface{}{1, nil}
+	s[1] = s
+
+	cm := snapshot(reflect.ValueOf(m))
+	m["n"] = 2
+	if got := cm.MapIndex(reflect.ValueOf("n")).Elem().Int(); got != 1 {
+		t.Errorf("snapshot of a map that contains itself changed with it: n = %d", got)
+	}
+	if !sameValue(cm, cm) {
+		t.Error("sameValue of a map that contains itself and itself: got false")
+	}
+	if sameValue(cm, reflect.ValueOf(m)) {
+		t.Error("sameValue of a map that contains itself and a changed copy: got true")
+	}
+
+	cs := snapshot(reflect.ValueOf(s))
+	if !sameValue(cs, reflect.ValueOf(s)) {
+		t.Error("sameValue of a slice that contains itself and its snapshot: got false")
+	}
+	s[0] = 2
+	if sameValue(cs, reflect.ValueOf(s)) {
+		t.Error("sameValue of a slice that contains itself and a changed copy: got true")
+	}
+}
+
+func TestSameValueNaN(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		x, y interface{}
+		want bool
+	}{
+		{nan, nan, true},
+		{float32(nan), float32(nan), true},
+		{complex(nan, 1), complex(nan, 1), true},
+		{[]float64{1, nan}, []float64{1, nan}, true},
+		{struct{ f float64 }{nan}, struct{ f float64 }{nan}, true},
+		{nan, 1.0, false},
+		{complex(nan, 1), complex(nan, 2), false},
+		{0.0, math.Copysign(0, -1), true},
+	}
+	for _, tt := range tests {
+		if got := sameValue(reflect.ValueOf(tt.x), reflect.ValueOf(tt.y)); got != tt.want {
+			t.Errorf("sameValue(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package godebug
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// In stop-the-world mode, goroutines other than the one being debugged wait at
+// their next line while the debugger is paused, so that the values the user
+// inspects do not change underneath them. It is off by default, and is turned
+// on by the -stoptheworld flag of godebug run and godebug test or from the prompt.
+var (
+	stopTheWorld int32
+
+	// worldStopped is not nil while the debugger is paused in stop-the-world
+	// mode. It is closed when the debugger resumes.
+	worldMu      sync.Mutex
+	worldStopped chan struct{}
+)
+
+// stopTheWorldEnvVar is set by the godebug command when it is given -stoptheworld.
+const stopTheWorldEnvVar = "GODEBUG_STOP_THE_WORLD"
+
+func init() {
+	if v, err := strconv.ParseBool(os.Getenv(stopTheWorldEnvVar)); err == nil && v {
+		stopTheWorld = 1
+	}
+}
+
+// stopWorld is called when the debugger pauses.
+func stopWorld() {
+	if atomic.LoadInt32(&stopTheWorld) == 0 {
+		return
+	}
+	worldMu.Lock()
+	if worldStopped == nil {
+		worldStopped = make(chan struct{})
+	}
+	worldMu.Unlock()
+}
+
+// startWorld is called when the debugger resumes, and lets waiting goroutines continue.
+func startWorld() {
+	worldMu.Lock()
+	if worldStopped != nil {
+		close(worldStopped)
+		worldStopped = nil
+	}
+	worldMu.Unlock()
+}
+
+// waitForWorld blocks the goroutine c belongs to until the debugger resumes,
+// if the world is stopped and c is not the goroutine being debugged.
+func waitForWorld(c *Context) {
+	if atomic.LoadInt32(&stopTheWorld) == 0 {
+		return
+	}
+	worldMu.Lock()
+	stopped := worldStopped
+	worldMu.Unlock()
+	if stopped != nil && atomic.LoadUint32(&currentGoroutine) != c.goroutine {
+		<-stopped
+	}
+}
+
+// toggleStopTheWorld handles the command "stoptheworld [on|off]".
+// Without an argument, it switches the mode.
+func toggleStopTheWorld(args string) {
+	on := atomic.LoadInt32(&stopTheWorld) == 0
+	switch args {
+	case "":
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		fmt.Printf(`Expected "stoptheworld", "stoptheworld on" or "stoptheworld off", but got %q.`+"\n", args)
+		return
+	}
+	if on {
+		atomic.StoreInt32(&stopTheWorld, 1)
+		stopWorld()
+		fmt.Println("Stop-the-world mode is on. Other goroutines will wait at their next line while the debugger is paused.")
+	} else {
+		atomic.StoreInt32(&stopTheWorld, 0)
+		startWorld()
+		fmt.Println("Stop-the-world mode is off. Other goroutines will keep running while the debugger is paused.")
+	}
+}
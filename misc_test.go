@@ -187,3 +187,18 @@ func overrideVar(cmd *exec.Cmd, key, val string) {
 	}
 	cmd.Env = append(cmd.Env, key+"="+val)
 }
+
+func TestBreakFlagRejectsLocations(t *testing.T) {
+	for _, spec := range []string{"worker.go:12", "12", "worker.go", "worker.go 12"} {
+		var l funcList
+		if err := l.Set(spec); err == nil {
+			t.Errorf("-break %q: got no error", spec)
+		}
+	}
+	for _, spec := range []string{"main.main", "'store\\..*Save'", "Handle"} {
+		var l funcList
+		if err := l.Set(spec); err != nil {
+			t.Errorf("-break %q: %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import "fmt"
+
+func visit(i int) {
+	_ = "breakpoint"
+	fmt.Println("visiting", i)
+}
+
+func main() {
+	for i := 0; i < 4; i++ {
+		visit(i)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var breakpoints_in_go_scope = godebug.EnteringNewScope(breakpoints_in_go_contents, "main/breakpoints-in.go", 6)
+
+func visit(i int) {
+	ctx, ok := godebug.EnterFunc("main.visit", "main/breakpoints-in.go", func() {
+		visit(i)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := breakpoints_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("i", "int", &i)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 6)
+	godebug.Line(ctx, scope, 7)
+
+	fmt.Println("visiting", i)
+	godebug.Returning(ctx)
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/breakpoints-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	{
+		scope := breakpoints_in_go_scope.EnteringNewChildScope()
+		for i := 0; i < 4; i++ {
+			godebug.Line(ctx, scope, 11)
+			scope.Declare("i", "int", &i)
+			godebug.Line(ctx, scope, 12)
+			visit(i)
+		}
+		godebug.Line(ctx, scope, 11)
+	}
+	godebug.Returning(ctx)
+}
+
+var breakpoints_in_go_contents = `package main
+
+import "fmt"
+
+func visit(i int) {
+	_ = "breakpoint"
+	fmt.Println("visiting", i)
+}
+
+func main() {
+	for i := 0; i < 4; i++ {
+		visit(i)
+	}
+}
+`
@@ -0,0 +1,30 @@
+package main
+
+import "fmt"
+
+type machine struct {
+	state string
+	count int
+}
+
+func (m *machine) feed(c byte) {
+	switch {
+	case c == ' ':
+		m.state = "space"
+	case m.state != "word":
+		m.state = "word"
+		m.count++
+	}
+}
+
+func main() {
+	m := machine{state: "start"}
+	input := "hi you"
+	last := byte(0)
+	_ = "breakpoint"
+	for i := 0; i < len(input); i++ {
+		last = input[i]
+		m.feed(last)
+	}
+	fmt.Println(m.count, last)
+}
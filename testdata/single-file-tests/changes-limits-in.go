@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+func main() {
+	seen := map[string]interface{}{}
+	seen["seen"] = seen
+	samples := make([]int, 20000)
+	_ = "breakpoint"
+	for i := 0; i < 2; i++ {
+		seen[fmt.Sprint(i)] = i
+		samples[i] = i + 1
+	}
+	fmt.Println(len(seen), samples[:2])
+}
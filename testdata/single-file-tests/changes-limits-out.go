@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var changes_limits_in_go_scope = godebug.EnteringNewScope(changes_limits_in_go_contents, "main/changes-limits-in.go", 9)
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/changes-limits-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, changes_limits_in_go_scope, 6)
+	seen := map[string]interface{}{}
+	scope := changes_limits_in_go_scope.EnteringNewChildScope()
+	scope.Declare("seen", "map[string]interface{}", &seen)
+	godebug.Line(ctx, scope, 7)
+	seen["seen"] = seen
+	godebug.Line(ctx, scope, 8)
+	samples := make([]int, 20000)
+	scope.Declare("samples", "[]int", &samples)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 9)
+	{
+		scope := scope.EnteringNewChildScope()
+
+		for i := 0; i < 2; i++ {
+			godebug.Line(ctx, scope, 10)
+			scope.Declare("i", "int", &i)
+			godebug.Line(ctx, scope, 11)
+			seen[fmt.Sprint(i)] = i
+			godebug.Line(ctx, scope, 12)
+			samples[i] = i + 1
+		}
+		godebug.Line(ctx, scope, 10)
+	}
+	godebug.Line(ctx, scope, 14)
+	fmt.Println(len(seen), samples[:2])
+	godebug.Returning(ctx)
+}
+
+var changes_limits_in_go_contents = `package main
+
+import "fmt"
+
+func main() {
+	seen := map[string]interface{}{}
+	seen["seen"] = seen
+	samples := make([]int, 20000)
+	_ = "breakpoint"
+	for i := 0; i < 2; i++ {
+		seen[fmt.Sprint(i)] = i
+		samples[i] = i + 1
+	}
+	fmt.Println(len(seen), samples[:2])
+}
+`
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var changes_in_go_scope = godebug.EnteringNewScope(changes_in_go_contents, "main/changes-in.go", 24)
+var _ = godebug.DeclareTypes("main", "machine", `type machine struct {
+    state string
+    count int
+}
+
+func (m *machine) feed(c byte)`)
+
+type machine struct {
+	state string
+	count int
+}
+
+func (m *machine) feed(c byte) {
+	ctx, ok := godebug.EnterFunc("main.(*machine).feed", "main/changes-in.go", func() {
+		m.feed(c)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := changes_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("m", "*machine", &m, "c", "byte", &c)
+	godebug.Line(ctx, scope, 11)
+	switch {
+	case godebug.Case(ctx, scope, 12):
+		fallthrough
+	case c == ' ':
+		godebug.Line(ctx, scope, 13)
+		m.state = "space"
+	case godebug.Case(ctx, scope, 14):
+		fallthrough
+	case m.state != "word":
+		godebug.Line(ctx, scope, 15)
+		m.state = "word"
+		godebug.Line(ctx, scope, 16)
+		m.count++
+	}
+	godebug.Returning(ctx)
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/changes-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, changes_in_go_scope, 21)
+	m := machine{state: "start"}
+	scope := changes_in_go_scope.EnteringNewChildScope()
+	scope.Declare("m", "machine", &m)
+	godebug.Line(ctx, scope, 22)
+	input := "hi you"
+	scope.Declare("input", "string", &input)
+	godebug.Line(ctx, scope, 23)
+	last := byte(0)
+	scope.Declare("last", "byte", &last)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 24)
+	{
+		scope := scope.EnteringNewChildScope()
+
+		for i := 0; i < len(input); i++ {
+			godebug.Line(ctx, scope, 25)
+			scope.Declare("i", "int", &i)
+			godebug.Line(ctx, scope, 26)
+			last = input[i]
+			godebug.Line(ctx, scope, 27)
+			m.feed(last)
+		}
+		godebug.Line(ctx, scope, 25)
+	}
+	godebug.Line(ctx, scope, 29)
+	fmt.Println(m.count, last)
+	godebug.Returning(ctx)
+}
+
+var changes_in_go_contents = `package main
+
+import "fmt"
+
+type machine struct {
+	state string
+	count int
+}
+
+func (m *machine) feed(c byte) {
+	switch {
+	case c == ' ':
+		m.state = "space"
+	case m.state != "word":
+		m.state = "word"
+		m.count++
+	}
+}
+
+func main() {
+	m := machine{state: "start"}
+	input := "hi you"
+	last := byte(0)
+	_ = "breakpoint"
+	for i := 0; i < len(input); i++ {
+		last = input[i]
+		m.feed(last)
+	}
+	fmt.Println(m.count, last)
+}
+`
@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+func push(queue []int, n int) []int {
+	_ = "assert: len(queue) < 3"
+	return append(queue, n)
+}
+
+func main() {
+	var queue []int
+	for i := 0; i < 5; i++ {
+		_ = `breakpoint if i%2 == 1 && len(queue) > 0`
+		queue = push(queue, i)
+	}
+	fmt.Println(queue)
+	_ =
+		"breakpoint if len(queue) == 5"
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var conditions_in_go_scope = godebug.EnteringNewScope(conditions_in_go_contents, "main/conditions-in.go", 6, 13, 17)
+var _ = godebug.DeclareCondition(conditions_in_go_scope, 6, "len(queue) < 3", true)
+var _ = godebug.DeclareCondition(conditions_in_go_scope, 13, "i%2 == 1 && len(queue) > 0", false)
+var _ = godebug.DeclareCondition(conditions_in_go_scope, 17, "len(queue) == 5", false)
+
+func push(queue []int, n int) (result1 []int) {
+	ctx, ok := godebug.EnterFunc("main.push", "main/conditions-in.go", func() {
+		result1 = push(queue, n)
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := conditions_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("queue", "[]int", &queue, "n", "int", &n)
+	if !(len(queue) < 3) {
+		godebug.SetTraceGen(ctx)
+	}
+	godebug.Line(ctx, scope, 6)
+	godebug.Line(ctx, scope, 7)
+
+	return append(queue, n)
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/conditions-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, conditions_in_go_scope, 11)
+	var queue []int
+	scope := conditions_in_go_scope.EnteringNewChildScope()
+	scope.Declare("queue", "[]int", &queue)
+	{
+		scope := scope.EnteringNewChildScope()
+		for i := 0; i < 5; i++ {
+			godebug.Line(ctx, scope, 12)
+			scope.Declare("i", "int", &i)
+			if i%2 == 1 && len(queue) > 0 {
+				godebug.SetTraceGen(ctx)
+			}
+			godebug.Line(ctx, scope, 13)
+			godebug.Line(ctx, scope, 14)
+
+			queue = push(queue, i)
+		}
+		godebug.Line(ctx, scope, 12)
+	}
+	godebug.Line(ctx, scope, 16)
+	fmt.Println(queue)
+	if len(queue) == 5 {
+		godebug.SetTraceGen(ctx)
+	}
+	godebug.Line(ctx, scope, 17)
+	godebug.Returning(ctx)
+
+}
+
+var conditions_in_go_contents = `package main
+
+import "fmt"
+
+func push(queue []int, n int) []int {
+	_ = "assert: len(queue) < 3"
+	return append(queue, n)
+}
+
+func main() {
+	var queue []int
+	for i := 0; i < 5; i++ {
+		_ = ` + "`" + `breakpoint if i%2 == 1 && len(queue) > 0` + "`" + `
+		queue = push(queue, i)
+	}
+	fmt.Println(queue)
+	_ =
+		"breakpoint if len(queue) == 5"
+}
+`
@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+func square(n int) int {
+	result := n * n
+	return result
+}
+
+func main() {
+	sum := 0
+	for i := 1; i <= 3; i++ {
+		_ = "breakpoint"
+		sum += square(i)
+	}
+	fmt.Println(sum)
+}
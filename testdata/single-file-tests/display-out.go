@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var display_in_go_scope = godebug.EnteringNewScope(display_in_go_contents, "main/display-in.go", 13)
+
+func square(n int) (_result1 int) {
+	ctx, ok := godebug.EnterFunc("main.square", "main/display-in.go", func() {
+		_result1 = square(n)
+	})
+	if !ok {
+		return _result1
+	}
+	defer godebug.ExitFunc(ctx, &_result1)
+	scope := display_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("n", "int", &n)
+	godebug.Line(ctx, scope, 6)
+	result := n * n
+	scope.Declare("result", "int", &result)
+	godebug.Line(ctx, scope, 7)
+	godebug.Returning(ctx)
+	return result
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/display-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, display_in_go_scope, 11)
+	sum := 0
+	scope := display_in_go_scope.EnteringNewChildScope()
+	scope.Declare("sum", "int", &sum)
+	{
+		scope := scope.EnteringNewChildScope()
+		for i := 1; i <= 3; i++ {
+			godebug.Line(ctx, scope, 12)
+			scope.Declare("i", "int", &i)
+			godebug.SetTraceGen(ctx)
+			godebug.Line(ctx, scope, 13)
+			godebug.Line(ctx, scope, 14)
+
+			sum += square(i)
+		}
+		godebug.Line(ctx, scope, 12)
+	}
+	godebug.Line(ctx, scope, 16)
+	fmt.Println(sum)
+	godebug.Returning(ctx)
+}
+
+var display_in_go_contents = `package main
+
+import "fmt"
+
+func square(n int) int {
+	result := n * n
+	return result
+}
+
+func main() {
+	sum := 0
+	for i := 1; i <= 3; i++ {
+		_ = "breakpoint"
+		sum += square(i)
+	}
+	fmt.Println(sum)
+}
+`
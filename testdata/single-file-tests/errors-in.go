@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errEmpty = errors.New("empty input")
+
+var calls int
+
+func parse(s string) (int, error) {
+	defer func() {
+		calls++
+	}()
+	if s == "" {
+		return 0, errEmpty
+	}
+	return strconv.Atoi(s)
+}
+
+func total(inputs []string) (int, error) {
+	sum := 0
+	for _, in := range inputs {
+		n, err := parse(in)
+		if err != nil {
+			return sum, err
+		}
+		sum += n
+	}
+	return sum, nil
+}
+
+func main() {
+	_ = "breakpoint"
+	fmt.Println(total([]string{"1", "x"}))
+	fmt.Println(total([]string{"1", ""}))
+	fmt.Println(total([]string{"2", "3"}))
+	fmt.Println(total([]string{""}))
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"github.com/mailgun/godebug/lib"
+	"fmt"
+	"strconv"
+)
+
+var errors_in_go_scope = godebug.EnteringNewScope(errors_in_go_contents, "main/errors-in.go", 36)
+
+var errEmpty = errors.New("empty input")
+
+var calls int
+
+func parse(s string) (result1 int, result2 error) {
+	ctx, ok := godebug.EnterFunc("main.parse", "main/errors-in.go", func() {
+		result1, result2 = parse(s)
+	})
+	if !ok {
+		return result1, result2
+	}
+	defer godebug.ExitFunc(ctx, &result1, &result2)
+	scope := errors_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("s", "string", &s)
+	godebug.Line(ctx, scope, 14)
+	defer func() {
+		fn := func(ctx *godebug.Context) {
+			scope := scope.EnteringNewChildScope()
+			scope.DeclareArgs()
+			godebug.Line(ctx, scope, 15)
+			calls++
+		}
+		if ctx, ok := godebug.EnterFuncLit("main.parse.func1", "main/errors-in.go", fn); ok {
+			defer godebug.ExitFunc(ctx)
+			fn(ctx)
+			godebug.Returning(ctx)
+		}
+	}()
+	defer godebug.Defer(ctx, scope, 14)
+	godebug.Line(ctx, scope, 17)
+	if s == "" {
+		godebug.Line(ctx, scope, 18)
+		godebug.Returning(ctx)
+		return 0, errEmpty
+	}
+	godebug.Line(ctx, scope, 20)
+	return strconv.Atoi(s)
+}
+
+func total(inputs []string) (result1 int, result2 error) {
+	ctx, ok := godebug.EnterFunc("main.total", "main/errors-in.go", func() {
+		result1, result2 = total(inputs)
+	})
+	if !ok {
+		return result1, result2
+	}
+	defer godebug.ExitFunc(ctx, &result1, &result2)
+	scope := errors_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("inputs", "[]string", &inputs)
+	godebug.Line(ctx, scope, 24)
+	sum := 0
+	scope.Declare("sum", "int", &sum)
+	{
+		scope := scope.EnteringNewChildScope()
+		for _, in := range inputs {
+			godebug.Line(ctx, scope, 25)
+			scope.Declare("in", "string", &in)
+			godebug.Line(ctx, scope, 26)
+			n, err := parse(in)
+			scope := scope.EnteringNewChildScope()
+			scope.Declare("n", "int", &n, "err", "error", &err)
+			godebug.Line(ctx, scope, 27)
+			if err != nil {
+				godebug.Line(ctx, scope, 28)
+				godebug.Returning(ctx)
+				return sum, err
+			}
+			godebug.Line(ctx, scope, 30)
+			sum += n
+		}
+		godebug.Line(ctx, scope, 25)
+	}
+	godebug.Line(ctx, scope, 32)
+	godebug.Returning(ctx)
+	return sum, nil
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/errors-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, errors_in_go_scope, 36)
+	godebug.Line(ctx, errors_in_go_scope, 37)
+
+	fmt.Println(total([]string{"1", "x"}))
+	godebug.Line(ctx, errors_in_go_scope, 38)
+	fmt.Println(total([]string{"1", ""}))
+	godebug.Line(ctx, errors_in_go_scope, 39)
+	fmt.Println(total([]string{"2", "3"}))
+	godebug.Line(ctx, errors_in_go_scope, 40)
+	fmt.Println(total([]string{""}))
+	godebug.Returning(ctx)
+}
+
+var errors_in_go_contents = `package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errEmpty = errors.New("empty input")
+
+var calls int
+
+func parse(s string) (int, error) {
+	defer func() {
+		calls++
+	}()
+	if s == "" {
+		return 0, errEmpty
+	}
+	return strconv.Atoi(s)
+}
+
+func total(inputs []string) (int, error) {
+	sum := 0
+	for _, in := range inputs {
+		n, err := parse(in)
+		if err != nil {
+			return sum, err
+		}
+		sum += n
+	}
+	return sum, nil
+}
+
+func main() {
+	_ = "breakpoint"
+	fmt.Println(total([]string{"1", "x"}))
+	fmt.Println(total([]string{"1", ""}))
+	fmt.Println(total([]string{"2", "3"}))
+	fmt.Println(total([]string{""}))
+}
+`
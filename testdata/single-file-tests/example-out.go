@@ -5,17 +5,18 @@ import (
 	"github.com/mailgun/godebug/lib"
 )
 
-var example_in_go_scope = godebug.EnteringNewScope(example_in_go_contents)
+var example_in_go_scope = godebug.EnteringNewScope(example_in_go_contents, "main/example-in.go", 7)
 
 func main() {
-	ctx, ok := godebug.EnterFunc(main)
+	ctx, ok := godebug.EnterFunc("main.main", "main/example-in.go", main)
 	if !ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.Line(ctx, example_in_go_scope, 6)
 	x := mul(1, 2)
 	scope := example_in_go_scope.EnteringNewChildScope()
-	scope.Declare("x", &x)
+	scope.Declare("x", "int", &x)
 	godebug.SetTraceGen(ctx)
 	godebug.Line(ctx, scope, 7)
 	godebug.Line(ctx, scope, 8)
@@ -38,58 +39,61 @@ func main() {
 			fmt.Println("What's going on? x ==", x)
 		}
 	}
+	godebug.Returning(ctx)
 }
 
-func add(n, m int) int {
-	var result1 int
-	ctx, ok := godebug.EnterFunc(func() {
+func add(n, m int) (result1 int) {
+	ctx, ok := godebug.EnterFunc("main.add", "main/example-in.go", func() {
 		result1 = add(n, m)
 	})
 	if !ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	scope := example_in_go_scope.EnteringNewChildScope()
-	scope.Declare("n", &n, "m", &m)
+	scope.DeclareArgs("n", "int", &n, "m", "int", &m)
 	godebug.Line(ctx, scope, 19)
 	if n == 0 {
 		godebug.Line(ctx, scope, 20)
+		godebug.Returning(ctx)
 		return m
 	}
 	godebug.Line(ctx, scope, 22)
 	if m == 0 {
 		godebug.Line(ctx, scope, 23)
+		godebug.Returning(ctx)
 		return n
 	}
 	godebug.Line(ctx, scope, 25)
+	godebug.Returning(ctx)
 	return n + m
 }
 
-func mul(n, m int) int {
-	var result1 int
-	ctx, ok := godebug.EnterFunc(func() {
+func mul(n, m int) (result1 int) {
+	ctx, ok := godebug.EnterFunc("main.mul", "main/example-in.go", func() {
 		result1 = mul(n, m)
 	})
 	if !ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	scope := example_in_go_scope.EnteringNewChildScope()
-	scope.Declare("n", &n, "m", &m)
+	scope.DeclareArgs("n", "int", &n, "m", "int", &m)
 	godebug.Line(ctx, scope, 29)
 	var x int
-	scope.Declare("x", &x)
+	scope.Declare("x", "int", &x)
 	{
 		scope := scope.EnteringNewChildScope()
 		for i := 0; i < m; i++ {
 			godebug.Line(ctx, scope, 30)
-			scope.Declare("i", &i)
+			scope.Declare("i", "int", &i)
 			godebug.Line(ctx, scope, 31)
 			x = add(x, m)
 		}
 		godebug.Line(ctx, scope, 30)
 	}
 	godebug.Line(ctx, scope, 33)
+	godebug.Returning(ctx)
 	return x
 }
 
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+type config struct {
+	verbose bool
+	retries int
+}
+
+func check(cfg config, logger *log.Logger) {
+	if cfg.retries < 0 {
+		log.Fatalf("negative retries: %d", cfg.retries)
+	}
+	if cfg.verbose && logger == nil {
+		log.Fatal("verbose needs a logger")
+	}
+	if cfg.retries > 10 {
+		logger.Fatalln("too many retries")
+	}
+}
+
+func finish(done int) {
+	defer fmt.Println("never printed")
+	if done > 0 {
+		os.Exit(0)
+	}
+}
+
+func main() {
+	_ = "breakpoint"
+	cfg := config{retries: 3}
+	check(cfg, log.New(os.Stderr, "", 0))
+	finish(2)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+	"log"
+	"os"
+)
+
+var exit_in_go_scope = godebug.EnteringNewScope(exit_in_go_contents, "main/exit-in.go", 34)
+var _ = godebug.DeclareTypes("main", "config", `type config struct {
+    verbose bool
+    retries int
+}`)
+
+type config struct {
+	verbose bool
+	retries int
+}
+
+func check(cfg config, logger *log.Logger) {
+	ctx, ok := godebug.EnterFunc("main.check", "main/exit-in.go", func() {
+		check(cfg, logger)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := exit_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("cfg", "config", &cfg, "logger", "*log.Logger", &logger)
+	godebug.Line(ctx, scope, 15)
+	if cfg.retries < 0 {
+		godebug.Line(ctx, scope, 16)
+		godebug.Fatalf(ctx, log.Fatalf, "negative retries: %d", cfg.retries)
+	}
+	godebug.Line(ctx, scope, 18)
+	if cfg.verbose && logger == nil {
+		godebug.Line(ctx, scope, 19)
+		godebug.Fatal(ctx, log.Fatal, "verbose needs a logger")
+	}
+	godebug.Line(ctx, scope, 21)
+	if cfg.retries > 10 {
+		godebug.Line(ctx, scope, 22)
+		godebug.Fatal(ctx, logger.Fatalln, "too many retries")
+	}
+	godebug.Returning(ctx)
+}
+
+func finish(done int) {
+	ctx, ok := godebug.EnterFunc("main.finish", "main/exit-in.go", func() {
+		finish(done)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := exit_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("done", "int", &done)
+	godebug.Line(ctx, scope, 27)
+	defer fmt.Println("never printed")
+	defer godebug.Defer(ctx, scope, 27)
+	godebug.Line(ctx, scope, 28)
+	if done > 0 {
+		godebug.Line(ctx, scope, 29)
+		godebug.Exit(ctx, os.Exit, 0)
+	}
+	godebug.Returning(ctx)
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/exit-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, exit_in_go_scope, 34)
+	godebug.Line(ctx, exit_in_go_scope, 35)
+
+	cfg := config{retries: 3}
+	scope := exit_in_go_scope.EnteringNewChildScope()
+	scope.Declare("cfg", "config", &cfg)
+	godebug.Line(ctx, scope, 36)
+	check(cfg, log.New(os.Stderr, "", 0))
+	godebug.Line(ctx, scope, 37)
+	finish(2)
+	godebug.Returning(ctx)
+}
+
+var exit_in_go_contents = `package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+type config struct {
+	verbose bool
+	retries int
+}
+
+func check(cfg config, logger *log.Logger) {
+	if cfg.retries < 0 {
+		log.Fatalf("negative retries: %d", cfg.retries)
+	}
+	if cfg.verbose && logger == nil {
+		log.Fatal("verbose needs a logger")
+	}
+	if cfg.retries > 10 {
+		logger.Fatalln("too many retries")
+	}
+}
+
+func finish(done int) {
+	defer fmt.Println("never printed")
+	if done > 0 {
+		os.Exit(0)
+	}
+}
+
+func main() {
+	_ = "breakpoint"
+	cfg := config{retries: 3}
+	check(cfg, log.New(os.Stderr, "", 0))
+	finish(2)
+}
+`
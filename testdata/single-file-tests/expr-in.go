@@ -0,0 +1,35 @@
+package main
+
+import "errors"
+
+type Base struct {
+	ID   int
+	name string
+}
+
+type Node struct {
+	Base
+	Value int
+	Next  *Node
+}
+
+type Request struct {
+	Header map[string]string
+	Body   []byte
+	Err    interface{}
+}
+
+func main() {
+	list := &Node{Base: Base{ID: 1, name: "first"}, Value: 10}
+	list.Next = &Node{Base: Base{ID: 2, name: "second"}, Value: 20}
+	req := Request{
+		Header: map[string]string{"X-Id": "abc"},
+		Body:   []byte("hello"),
+		Err:    errors.New("oops"),
+	}
+	items := []int{1, 2, 3, 4, 5}
+	grid := [2][3]int{{1, 2, 3}, {4, 5, 6}}
+	var missing *Node
+	_ = "breakpoint"
+	_, _, _, _, _ = list, req, items, grid, missing
+}
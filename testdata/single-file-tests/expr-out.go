@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"github.com/mailgun/godebug/lib"
+)
+
+var expr_in_go_scope = godebug.EnteringNewScope(expr_in_go_contents, "main/expr-in.go", 33)
+var _ = godebug.DeclareTypes("main", "Base", `type Base struct {
+    ID   int
+    name string
+}`, "Node", `type Node struct {
+    Base
+    Value int
+    Next  *Node
+}`, "Request", `type Request struct {
+    Header map[string]string
+    Body   []byte
+    Err    interface{}
+}`)
+
+type Base struct {
+	ID   int
+	name string
+}
+
+type Node struct {
+	Base
+	Value int
+	Next  *Node
+}
+
+type Request struct {
+	Header map[string]string
+	Body   []byte
+	Err    interface{}
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/expr-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, expr_in_go_scope, 23)
+	list := &Node{Base: Base{ID: 1, name: "first"}, Value: 10}
+	scope := expr_in_go_scope.EnteringNewChildScope()
+	scope.Declare("list", "*Node", &list)
+	godebug.Line(ctx, scope, 24)
+	list.Next = &Node{Base: Base{ID: 2, name: "second"}, Value: 20}
+	godebug.Line(ctx, scope, 25)
+	req := Request{
+		Header: map[string]string{"X-Id": "abc"},
+		Body:   []byte("hello"),
+		Err:    errors.New("oops"),
+	}
+	scope.Declare("req", "Request", &req)
+	godebug.Line(ctx, scope, 30)
+
+	items := []int{1, 2, 3, 4, 5}
+	scope.Declare("items", "[]int", &items)
+	godebug.Line(ctx, scope, 31)
+	grid := [2][3]int{{1, 2, 3}, {4, 5, 6}}
+	scope.Declare("grid", "[2][3]int", &grid)
+	godebug.Line(ctx, scope, 32)
+	var missing *Node
+	scope.Declare("missing", "*Node", &missing)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 33)
+	godebug.Line(ctx, scope, 34)
+
+	_, _, _, _, _ = list, req, items, grid, missing
+	godebug.Returning(ctx)
+}
+
+var expr_in_go_contents = `package main
+
+import "errors"
+
+type Base struct {
+	ID   int
+	name string
+}
+
+type Node struct {
+	Base
+	Value int
+	Next  *Node
+}
+
+type Request struct {
+	Header map[string]string
+	Body   []byte
+	Err    interface{}
+}
+
+func main() {
+	list := &Node{Base: Base{ID: 1, name: "first"}, Value: 10}
+	list.Next = &Node{Base: Base{ID: 2, name: "second"}, Value: 20}
+	req := Request{
+		Header: map[string]string{"X-Id": "abc"},
+		Body:   []byte("hello"),
+		Err:    errors.New("oops"),
+	}
+	items := []int{1, 2, 3, 4, 5}
+	grid := [2][3]int{{1, 2, 3}, {4, 5, 6}}
+	var missing *Node
+	_ = "breakpoint"
+	_, _, _, _, _ = list, req, items, grid, missing
+}
+`
@@ -0,0 +1,25 @@
+package main
+
+import "fmt"
+
+type header struct {
+	Flags  uint8
+	Length int16
+	Tag    [4]byte
+}
+
+type color int
+
+func (c color) String() string { return "color" }
+
+func main() {
+	flags := uint32(0x8041)
+	delta := -42
+	b := byte('G')
+	buf := []byte("GET /index.html HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	h := header{Flags: 0x1f, Length: -2, Tag: [4]byte{'p', 'i', 'n', 'g'}}
+	c := color(10)
+	name := "héllo"
+	_ = "breakpoint"
+	fmt.Println(flags, delta, b, len(buf), h, c, name)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var format_in_go_scope = godebug.EnteringNewScope(format_in_go_contents, "main/format-in.go", 23)
+var _ = godebug.DeclareTypes("main", "color", `type color int
+
+func (c color) String() string`, "header", `type header struct {
+    Flags  uint8
+    Length int16
+    Tag    [4]byte
+}`)
+
+type header struct {
+	Flags  uint8
+	Length int16
+	Tag    [4]byte
+}
+
+type color int
+
+func (c color) String() (result1 string) {
+	ctx, ok := godebug.EnterFunc("main.color.String", "main/format-in.go", func() {
+		result1 = c.String()
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := format_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("c", "color", &c)
+	godebug.Line(ctx, scope, 13)
+	godebug.Returning(ctx)
+	return "color"
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/format-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, format_in_go_scope, 16)
+	flags := uint32(0x8041)
+	scope := format_in_go_scope.EnteringNewChildScope()
+	scope.Declare("flags", "uint32", &flags)
+	godebug.Line(ctx, scope, 17)
+	delta := -42
+	scope.Declare("delta", "int", &delta)
+	godebug.Line(ctx, scope, 18)
+	b := byte('G')
+	scope.Declare("b", "byte", &b)
+	godebug.Line(ctx, scope, 19)
+	buf := []byte("GET /index.html HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	scope.Declare("buf", "[]byte", &buf)
+	godebug.Line(ctx, scope, 20)
+	h := header{Flags: 0x1f, Length: -2, Tag: [4]byte{'p', 'i', 'n', 'g'}}
+	scope.Declare("h", "header", &h)
+	godebug.Line(ctx, scope, 21)
+	c := color(10)
+	scope.Declare("c", "color", &c)
+	godebug.Line(ctx, scope, 22)
+	name := "héllo"
+	scope.Declare("name", "string", &name)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 23)
+	godebug.Line(ctx, scope, 24)
+
+	fmt.Println(flags, delta, b, len(buf), h, c, name)
+	godebug.Returning(ctx)
+}
+
+var format_in_go_contents = `package main
+
+import "fmt"
+
+type header struct {
+	Flags  uint8
+	Length int16
+	Tag    [4]byte
+}
+
+type color int
+
+func (c color) String() string { return "color" }
+
+func main() {
+	flags := uint32(0x8041)
+	delta := -42
+	b := byte('G')
+	buf := []byte("GET /index.html HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	h := header{Flags: 0x1f, Length: -2, Tag: [4]byte{'p', 'i', 'n', 'g'}}
+	c := color(10)
+	name := "héllo"
+	_ = "breakpoint"
+	fmt.Println(flags, delta, b, len(buf), h, c, name)
+}
+`
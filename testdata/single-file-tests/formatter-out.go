@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mailgun/godebug/lib"
+)
+
+var formatter_in_go_scope = godebug.EnteringNewScope(formatter_in_go_contents, "main/formatter-in.go", 45)
+var _ = godebug.DeclareTypes("main", "money", `type money struct {
+    cents    int64
+    currency string
+}`, "order", `type order struct {
+    ID    userID
+    Total money
+    Items map[string]money
+    Note  interface{}
+}`, "userID", `type userID [4]byte`)
+
+type money struct {
+	cents    int64
+	currency string
+}
+
+type userID [4]byte
+
+type order struct {
+	ID    userID
+	Total money
+	Items map[string]money
+	Note  interface{}
+}
+
+func init() {
+	godebug.RegisterFormatter(money{}, func(v interface{}) string {
+		m := v.(money)
+		return fmt.Sprintf("%d.%02d %s", m.cents/100, m.cents%100, m.currency)
+	})
+	godebug.RegisterFormatter(userID{}, func(v interface{}) string {
+		id := v.(userID)
+		return fmt.Sprintf("user-%x", id[:])
+	})
+	godebug.RegisterFormatter((*order)(nil), func(v interface{}) string {
+		return "order of " + v.(*order).Total.currency
+	})
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/formatter-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, formatter_in_go_scope, 38)
+	o := order{
+		ID:    userID{0xde, 0xad, 0xbe, 0xef},
+		Total: money{1250, "EUR"},
+		Items: map[string]money{"tea": {450, "EUR"}, "cake": {800, "EUR"}},
+		Note:  money{5, "EUR"},
+	}
+	scope := formatter_in_go_scope.EnteringNewChildScope()
+	scope.Declare("o", "order", &o)
+	godebug.Line(ctx, scope, 44)
+
+	var missing *order
+	scope.Declare("missing", "*order", &missing)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 45)
+	godebug.Line(ctx, scope, 46)
+
+	fmt.Println(o.Total.cents, missing)
+	godebug.Returning(ctx)
+}
+
+var formatter_in_go_contents = `package main
+
+import (
+	"fmt"
+
+	"github.com/mailgun/godebug/lib"
+)
+
+type money struct {
+	cents    int64
+	currency string
+}
+
+type userID [4]byte
+
+type order struct {
+	ID    userID
+	Total money
+	Items map[string]money
+	Note  interface{}
+}
+
+func init() {
+	godebug.RegisterFormatter(money{}, func(v interface{}) string {
+		m := v.(money)
+		return fmt.Sprintf("%d.%02d %s", m.cents/100, m.cents%100, m.currency)
+	})
+	godebug.RegisterFormatter(userID{}, func(v interface{}) string {
+		id := v.(userID)
+		return fmt.Sprintf("user-%x", id[:])
+	})
+	godebug.RegisterFormatter((*order)(nil), func(v interface{}) string {
+		return "order of " + v.(*order).Total.currency
+	})
+}
+
+func main() {
+	o := order{
+		ID:    userID{0xde, 0xad, 0xbe, 0xef},
+		Total: money{1250, "EUR"},
+		Items: map[string]money{"tea": {450, "EUR"}, "cake": {800, "EUR"}},
+		Note:  money{5, "EUR"},
+	}
+	var missing *order
+	_ = "breakpoint"
+	fmt.Println(o.Total.cents, missing)
+}
+`
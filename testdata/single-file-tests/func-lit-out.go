@@ -5,21 +5,23 @@ import (
 	"github.com/mailgun/godebug/lib"
 )
 
-var func_lit_in_go_scope = godebug.EnteringNewScope(func_lit_in_go_contents)
+var func_lit_in_go_scope = godebug.EnteringNewScope(func_lit_in_go_contents, "main/func-lit-in.go")
 
 func main() {
-	ctx, ok := godebug.EnterFunc(main)
+	ctx, ok := godebug.EnterFunc("main.main", "main/func-lit-in.go", main)
 	if !ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.Line(ctx, func_lit_in_go_scope, 6)
 	hi, there := foo(7, 12)
 	scope := func_lit_in_go_scope.EnteringNewChildScope()
-	scope.Declare("hi", &hi, "there", &there)
+	scope.Declare("hi", "string", &hi, "there", "string", &there)
 	godebug.Line(ctx, scope, 7)
 	fmt.Println(hi, there)
 	godebug.Line(ctx, scope, 8)
 	bar()
+	godebug.Returning(ctx)
 }
 
 var foo = func(a, _ int) (b, _ string) {
@@ -27,26 +29,31 @@ var foo = func(a, _ int) (b, _ string) {
 	fn := func(ctx *godebug.Context) {
 		b, result2 = func() (b, _ string) {
 			scope := func_lit_in_go_scope.EnteringNewChildScope()
-			scope.Declare("a", &a, "b", &b)
+			scope.DeclareArgs("a", "int", &a)
+			scope.Declare("b", "string", &b)
 			godebug.Line(ctx, scope, 12)
 			return "Hello", "World"
 		}()
 	}
-	if ctx, ok := godebug.EnterFuncLit(fn); ok {
-		defer godebug.ExitFunc(ctx)
+	if ctx, ok := godebug.EnterFuncLit("main.glob..func1", "main/func-lit-in.go", fn); ok {
+		defer godebug.ExitFunc(ctx, &b, &result2)
 		fn(ctx)
+		godebug.Returning(ctx)
 	}
 	return b, result2
 }
 
 var bar = func() {
 	fn := func(ctx *godebug.Context) {
-		godebug.Line(ctx, func_lit_in_go_scope, 16)
+		scope := func_lit_in_go_scope.EnteringNewChildScope()
+		scope.DeclareArgs()
+		godebug.Line(ctx, scope, 16)
 		fmt.Println("No inputs or outputs")
 	}
-	if ctx, ok := godebug.EnterFuncLit(fn); ok {
+	if ctx, ok := godebug.EnterFuncLit("main.glob..func2", "main/func-lit-in.go", fn); ok {
 		defer godebug.ExitFunc(ctx)
 		fn(ctx)
+		godebug.Returning(ctx)
 	}
 }
 
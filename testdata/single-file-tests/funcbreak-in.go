@@ -0,0 +1,30 @@
+package main
+
+import "fmt"
+
+type Store struct {
+	items map[string]int
+}
+
+func (s *Store) Save(key string, n int) {
+	s.items[key] = n
+}
+
+func (s Store) Load(key string) int {
+	return s.items[key]
+}
+
+func process(s *Store, keys ...string) {
+	for i, key := range keys {
+		s.Save(key, i)
+	}
+}
+
+func main() {
+	s := &Store{items: map[string]int{}}
+	_ = "breakpoint"
+	process(s, "a", "b", "c")
+	func() {
+		fmt.Println(s.Load("b"))
+	}()
+}
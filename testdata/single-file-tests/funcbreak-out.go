@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var funcbreak_in_go_scope = godebug.EnteringNewScope(funcbreak_in_go_contents, "main/funcbreak-in.go", 25)
+var _ = godebug.DeclareTypes("main", "Store", `type Store struct {
+    items map[string]int
+}
+
+func (s *Store) Save(key string, n int)
+func (s Store) Load(key string) int`)
+
+type Store struct {
+	items map[string]int
+}
+
+func (s *Store) Save(key string, n int) {
+	ctx, ok := godebug.EnterFunc("main.(*Store).Save", "main/funcbreak-in.go", func() {
+		s.Save(key, n)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := funcbreak_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("s", "*Store", &s, "key", "string", &key, "n", "int", &n)
+	godebug.Line(ctx, scope, 10)
+	s.items[key] = n
+	godebug.Returning(ctx)
+}
+
+func (s Store) Load(key string) (result1 int) {
+	ctx, ok := godebug.EnterFunc("main.Store.Load", "main/funcbreak-in.go", func() {
+		result1 = s.Load(key)
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := funcbreak_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("s", "Store", &s, "key", "string", &key)
+	godebug.Line(ctx, scope, 14)
+	return s.items[key]
+}
+
+func process(s *Store, keys ...string) {
+	ctx, ok := godebug.EnterFunc("main.process", "main/funcbreak-in.go", func() {
+		process(s, keys...)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := funcbreak_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("s", "*Store", &s, "keys", "[]string", &keys)
+	{
+		scope := scope.EnteringNewChildScope()
+		for i, key := range keys {
+			godebug.Line(ctx, scope, 18)
+			scope.Declare("i", "int", &i, "key", "string", &key)
+			godebug.Line(ctx, scope, 19)
+			s.Save(key, i)
+		}
+		godebug.Line(ctx, scope, 18)
+	}
+	godebug.Returning(ctx)
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/funcbreak-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, funcbreak_in_go_scope, 24)
+	s := &Store{items: map[string]int{}}
+	scope := funcbreak_in_go_scope.EnteringNewChildScope()
+	scope.Declare("s", "*Store", &s)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 25)
+	godebug.Line(ctx, scope, 26)
+
+	process(s, "a", "b", "c")
+	godebug.Line(ctx, scope, 27)
+	func() {
+		fn := func(ctx *godebug.Context) {
+			scope := scope.EnteringNewChildScope()
+			scope.DeclareArgs()
+			godebug.Line(ctx, scope, 28)
+			fmt.Println(s.Load("b"))
+		}
+		if ctx, ok := godebug.EnterFuncLit("main.main.func1", "main/funcbreak-in.go", fn); ok {
+			defer godebug.ExitFunc(ctx)
+			fn(ctx)
+			godebug.Returning(ctx)
+		}
+	}()
+	godebug.Returning(ctx)
+}
+
+var funcbreak_in_go_contents = `package main
+
+import "fmt"
+
+type Store struct {
+	items map[string]int
+}
+
+func (s *Store) Save(key string, n int) {
+	s.items[key] = n
+}
+
+func (s Store) Load(key string) int {
+	return s.items[key]
+}
+
+func process(s *Store, keys ...string) {
+	for i, key := range keys {
+		s.Save(key, i)
+	}
+}
+
+func main() {
+	s := &Store{items: map[string]int{}}
+	_ = "breakpoint"
+	process(s, "a", "b", "c")
+	func() {
+		fmt.Println(s.Load("b"))
+	}()
+}
+`
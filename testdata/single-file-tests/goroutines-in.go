@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	mu      sync.Mutex
+	started = sync.NewCond(&mu)
+	release = sync.NewCond(&mu)
+	results = make(chan int)
+	done    = make(chan bool)
+)
+
+// worker waits in release.Wait until main lets it go. Because main holds mu
+// from then on, each worker has run that line by the time main continues.
+func worker(id int, work chan int) {
+	mu.Lock()
+	started.Signal()
+	release.Wait()
+	mu.Unlock()
+	n := <-work
+	n = exchange(id, n, work)
+	done <- true
+}
+
+func exchange(id, n int, work chan int) int {
+	results <- n * id
+	return <-work
+}
+
+func main() {
+	work1, work2 := make(chan int), make(chan int)
+	mu.Lock()
+	go worker(1, work1)
+	started.Wait()
+	go worker(2, work2)
+	started.Wait()
+	_ = "breakpoint"
+	release.Broadcast()
+	mu.Unlock()
+	work1 <- 10
+	fmt.Println("result", <-results)
+	work2 <- 20
+	fmt.Println("result", <-results)
+	work1 <- 0
+	<-done
+	work2 <- 0
+	<-done
+}
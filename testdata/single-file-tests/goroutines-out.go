@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+	"sync"
+)
+
+var goroutines_in_go_scope = godebug.EnteringNewScope(goroutines_in_go_contents, "main/goroutines-in.go", 40)
+
+var (
+	mu      sync.Mutex
+	started = sync.NewCond(&mu)
+	release = sync.NewCond(&mu)
+	results = make(chan int)
+	done    = make(chan bool)
+)
+
+func worker(id int, work chan int) {
+	ctx, ok := godebug.EnterFunc("main.worker", "main/goroutines-in.go", func() {
+		worker(id, work)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := goroutines_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("id", "int", &id, "work", "chan int", &work)
+	godebug.Line(ctx, scope, 19)
+	mu.Lock()
+	godebug.Line(ctx, scope, 20)
+	started.Signal()
+	godebug.Line(ctx, scope, 21)
+	release.Wait()
+	godebug.Line(ctx, scope, 22)
+	mu.Unlock()
+	godebug.Line(ctx, scope, 23)
+	n := <-work
+	scope.Declare("n", "int", &n)
+	godebug.Line(ctx, scope, 24)
+	n = exchange(id, n, work)
+	godebug.Line(ctx, scope, 25)
+	done <- true
+	godebug.Returning(ctx)
+}
+
+func exchange(id, n int, work chan int) (result1 int) {
+	ctx, ok := godebug.EnterFunc("main.exchange", "main/goroutines-in.go", func() {
+		result1 = exchange(id, n, work)
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := goroutines_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("id", "int", &id, "n", "int", &n, "work", "chan int", &work)
+	godebug.Line(ctx, scope, 29)
+	results <- n * id
+	godebug.Line(ctx, scope, 30)
+	return <-work
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/goroutines-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, goroutines_in_go_scope, 34)
+	work1, work2 := make(chan int), make(chan int)
+	scope := goroutines_in_go_scope.EnteringNewChildScope()
+	scope.Declare("work1", "chan int", &work1, "work2", "chan int", &work2)
+	godebug.Line(ctx, scope, 35)
+	mu.Lock()
+	godebug.Line(ctx, scope, 36)
+	go worker(1, work1)
+	godebug.Line(ctx, scope, 37)
+	started.Wait()
+	godebug.Line(ctx, scope, 38)
+	go worker(2, work2)
+	godebug.Line(ctx, scope, 39)
+	started.Wait()
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 40)
+	godebug.Line(ctx, scope, 41)
+
+	release.Broadcast()
+	godebug.Line(ctx, scope, 42)
+	mu.Unlock()
+	godebug.Line(ctx, scope, 43)
+	work1 <- 10
+	godebug.Line(ctx, scope, 44)
+	fmt.Println("result", <-results)
+	godebug.Line(ctx, scope, 45)
+	work2 <- 20
+	godebug.Line(ctx, scope, 46)
+	fmt.Println("result", <-results)
+	godebug.Line(ctx, scope, 47)
+	work1 <- 0
+	godebug.Line(ctx, scope, 48)
+	<-done
+	godebug.Line(ctx, scope, 49)
+	work2 <- 0
+	godebug.Line(ctx, scope, 50)
+	<-done
+	godebug.Returning(ctx)
+}
+
+var goroutines_in_go_contents = `package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	mu      sync.Mutex
+	started = sync.NewCond(&mu)
+	release = sync.NewCond(&mu)
+	results = make(chan int)
+	done    = make(chan bool)
+)
+
+// worker waits in release.Wait until main lets it go. Because main holds mu
+// from then on, each worker has run that line by the time main continues.
+func worker(id int, work chan int) {
+	mu.Lock()
+	started.Signal()
+	release.Wait()
+	mu.Unlock()
+	n := <-work
+	n = exchange(id, n, work)
+	done <- true
+}
+
+func exchange(id, n int, work chan int) int {
+	results <- n * id
+	return <-work
+}
+
+func main() {
+	work1, work2 := make(chan int), make(chan int)
+	mu.Lock()
+	go worker(1, work1)
+	started.Wait()
+	go worker(2, work2)
+	started.Wait()
+	_ = "breakpoint"
+	release.Broadcast()
+	mu.Unlock()
+	work1 <- 10
+	fmt.Println("result", <-results)
+	work2 <- 20
+	fmt.Println("result", <-results)
+	work1 <- 0
+	<-done
+	work2 <- 0
+	<-done
+}
+`
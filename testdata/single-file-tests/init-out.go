@@ -2,7 +2,10 @@ package main
 
 import "github.com/mailgun/godebug/lib"
 
-var init_in_go_scope = godebug.EnteringNewScope(init_in_go_contents)
+var init_in_go_scope = godebug.EnteringNewScope(init_in_go_contents, "main/init-in.go")
+var _ = godebug.DeclareTypes("main", "Foo", `type Foo int
+
+func (f *Foo) init()`)
 
 func init() {
 	a = 5
@@ -13,15 +16,16 @@ var a = 3
 type Foo int
 
 func (f *Foo) init() {
-	ctx, ok := godebug.EnterFunc(f.init)
+	ctx, ok := godebug.EnterFunc("main.(*Foo).init", "main/init-in.go", f.init)
 	if !ok {
 		return
 	}
 	defer godebug.ExitFunc(ctx)
 	scope := init_in_go_scope.EnteringNewChildScope()
-	scope.Declare("f", &f)
+	scope.DeclareArgs("f", "*Foo", &f)
 	godebug.Line(ctx, scope, 14)
 	*f = 1337
+	godebug.Returning(ctx)
 }
 
 func main() {
@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+type job struct {
+	id    int
+	state string
+}
+
+func work(j *job) {
+	_ = "logpoint: starting job {j.id}"
+	j.state = "done"
+	fmt.Println("worked on", j.id)
+}
+
+func main() {
+	jobs := []*job{{1, "new"}, {2, "new"}, {3, "new"}}
+	_ = "breakpoint"
+	for i, j := range jobs {
+		work(j)
+		fmt.Println(i, j.state)
+	}
+}
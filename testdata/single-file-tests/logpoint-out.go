@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var logpoint_in_go_scope = godebug.EnteringNewScope(logpoint_in_go_contents, "main/logpoint-in.go", 18)
+var _ = godebug.DeclareTypes("main", "job", `type job struct {
+    id    int
+    state string
+}`)
+
+type job struct {
+	id    int
+	state string
+}
+
+func work(j *job) {
+	ctx, ok := godebug.EnterFunc("main.work", "main/logpoint-in.go", func() {
+		work(j)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := logpoint_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("j", "*job", &j)
+	godebug.Logpoint(ctx, "starting job {j.id}")
+	godebug.Line(ctx, scope, 11)
+	godebug.Line(ctx, scope, 12)
+
+	j.state = "done"
+	godebug.Line(ctx, scope, 13)
+	fmt.Println("worked on", j.id)
+	godebug.Returning(ctx)
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/logpoint-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, logpoint_in_go_scope, 17)
+	jobs := []*job{{1, "new"}, {2, "new"}, {3, "new"}}
+	scope := logpoint_in_go_scope.EnteringNewChildScope()
+	scope.Declare("jobs", "[]*job", &jobs)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 18)
+	{
+		scope := scope.EnteringNewChildScope()
+
+		for i, j := range jobs {
+			godebug.Line(ctx, scope, 19)
+			scope.Declare("i", "int", &i, "j", "*job", &j)
+			godebug.Line(ctx, scope, 20)
+			work(j)
+			godebug.Line(ctx, scope, 21)
+			fmt.Println(i, j.state)
+		}
+		godebug.Line(ctx, scope, 19)
+	}
+	godebug.Returning(ctx)
+}
+
+var logpoint_in_go_contents = `package main
+
+import "fmt"
+
+type job struct {
+	id    int
+	state string
+}
+
+func work(j *job) {
+	_ = "logpoint: starting job {j.id}"
+	j.state = "done"
+	fmt.Println("worked on", j.id)
+}
+
+func main() {
+	jobs := []*job{{1, "new"}, {2, "new"}, {3, "new"}}
+	_ = "breakpoint"
+	for i, j := range jobs {
+		work(j)
+		fmt.Println(i, j.state)
+	}
+}
+`
@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+type tag string
+
+func (t tag) String() string {
+	_ = "breakpoint"
+	return "#" + string(t)
+}
+
+func main() {
+	t := tag("urgent")
+	_ = "breakpoint"
+	_ = "logpoint: tagged {t}"
+	fmt.Println("done")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var logstring_in_go_scope = godebug.EnteringNewScope(logstring_in_go_contents, "main/logstring-in.go", 8, 14)
+var _ = godebug.DeclareTypes("main", "tag", `type tag string
+
+func (t tag) String() string`)
+
+type tag string
+
+func (t tag) String() (result1 string) {
+	ctx, ok := godebug.EnterFunc("main.tag.String", "main/logstring-in.go", func() {
+		result1 = t.String()
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := logstring_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("t", "tag", &t)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 8)
+	godebug.Line(ctx, scope, 9)
+
+	return "#" + string(t)
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/logstring-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, logstring_in_go_scope, 13)
+	t := tag("urgent")
+	scope := logstring_in_go_scope.EnteringNewChildScope()
+	scope.Declare("t", "tag", &t)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 14)
+	godebug.Logpoint(ctx, "tagged {t}")
+	godebug.Line(ctx, scope, 15)
+	godebug.Line(ctx, scope, 16)
+
+	fmt.Println("done")
+	godebug.Returning(ctx)
+}
+
+var logstring_in_go_contents = `package main
+
+import "fmt"
+
+type tag string
+
+func (t tag) String() string {
+	_ = "breakpoint"
+	return "#" + string(t)
+}
+
+func main() {
+	t := tag("urgent")
+	_ = "breakpoint"
+	_ = "logpoint: tagged {t}"
+	fmt.Println("done")
+}
+`
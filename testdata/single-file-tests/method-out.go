@@ -2,46 +2,51 @@ package main
 
 import "github.com/mailgun/godebug/lib"
 
-var method_in_go_scope = godebug.EnteringNewScope(method_in_go_contents)
+var method_in_go_scope = godebug.EnteringNewScope(method_in_go_contents, "main/method-in.go")
+var _ = godebug.DeclareTypes("main", "Foo", `type Foo int
+
+func (f Foo) Double() Foo
+func (Foo) Seven() Foo`)
 
 type Foo int
 
-func (f Foo) Double() Foo {
-	var result1 Foo
-	ctx, ok := godebug.EnterFunc(func() {
+func (f Foo) Double() (result1 Foo) {
+	ctx, ok := godebug.EnterFunc("main.Foo.Double", "main/method-in.go", func() {
 		result1 = f.Double()
 	})
 	if !ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	scope := method_in_go_scope.EnteringNewChildScope()
-	scope.Declare("f", &f)
+	scope.DeclareArgs("f", "Foo", &f)
 	godebug.Line(ctx, scope, 6)
+	godebug.Returning(ctx)
 	return f * 2
 }
 
-func (Foo) Seven() Foo {
-	var result1 Foo
+func (Foo) Seven() (result1 Foo) {
 	var receiver Foo
-	ctx, ok := godebug.EnterFunc(func() {
+	ctx, ok := godebug.EnterFunc("main.Foo.Seven", "main/method-in.go", func() {
 		result1 = receiver.Seven()
 	})
 	if !ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	godebug.Line(ctx, method_in_go_scope, 10)
 	return Foo(7)
 }
 
 func main() {
-	ctx, ok := godebug.EnterFunc(main)
+	ctx, ok := godebug.EnterFunc("main.main", "main/method-in.go", main)
 	if !ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.Line(ctx, method_in_go_scope, 14)
 	Foo(3).Double()
+	godebug.Returning(ctx)
 }
 
 var method_in_go_contents = `package main
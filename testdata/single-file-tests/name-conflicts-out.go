@@ -5,64 +5,70 @@ import (
 	_godebug "github.com/mailgun/godebug/lib"
 )
 
-var name_conflicts_in_go_scope = _godebug.EnteringNewScope(name_conflicts_in_go_contents)
+var name_conflicts_in_go_scope = _godebug.EnteringNewScope(name_conflicts_in_go_contents, "main/name-conflicts-in.go")
+var _ = _godebug.DeclareTypes("main", "Foo", `type Foo int
+
+func (Foo) DoStuff(int) int`)
 
 type Foo int
 
-func (Foo) DoStuff(int) int {
+func (Foo) DoStuff(int) (_result1 int) {
 	var _input1 int
-	var _result1 int
 	var _receiver Foo
-	_ctx, __ok := _godebug.EnterFunc(func() {
+	_ctx, __ok := _godebug.EnterFunc("main.Foo.DoStuff", "main/name-conflicts-in.go", func() {
 		_result1 = _receiver.DoStuff(_input1)
 	})
 	if !__ok {
 		return _result1
 	}
-	defer _godebug.ExitFunc(_ctx)
+	defer _godebug.ExitFunc(_ctx, &_result1)
 	_godebug.Line(_ctx, name_conflicts_in_go_scope, 8)
 	var fn, ok, _ok, ctx, result1, input1, receiver, name_conflicts_in_goScope, scope int
 	__scope := name_conflicts_in_go_scope.EnteringNewChildScope()
-	__scope.Declare("fn", &fn, "ok", &ok, "_ok", &_ok, "ctx", &ctx, "result1", &result1, "input1", &input1, "receiver", &receiver, "name_conflicts_in_goScope", &name_conflicts_in_goScope, "scope", &scope)
+	__scope.Declare("fn", "int", &fn, "ok", "int", &ok, "_ok", "int", &_ok, "ctx", "int", &ctx, "result1", "int", &result1, "input1", "int", &input1, "receiver", "int", &receiver, "name_conflicts_in_goScope", "int", &name_conflicts_in_goScope, "scope", "int", &scope)
 	_godebug.Line(_ctx, __scope, 9)
 	godebug.Println(fn, ok, _ok, ctx, result1, input1, receiver, name_conflicts_in_goScope, scope, _scope)
 	_godebug.Line(_ctx, __scope, 10)
+	_godebug.Returning(_ctx)
 	return 3
 }
 
 var f = func() {
 	fn := func(_ctx *_godebug.Context) {
-		_godebug.Line(_ctx, name_conflicts_in_go_scope, 14)
-		var fn, ok, _ok, ctx, result1, input1, receiver, name_conflicts_in_goScope, scope int
 		__scope := name_conflicts_in_go_scope.EnteringNewChildScope()
-		__scope.Declare("fn", &fn, "ok", &ok, "_ok", &_ok, "ctx", &ctx, "result1", &result1, "input1", &input1, "receiver", &receiver, "name_conflicts_in_goScope", &name_conflicts_in_goScope, "scope", &scope)
+		__scope.DeclareArgs()
+		_godebug.Line(_ctx, __scope, 14)
+		var fn, ok, _ok, ctx, result1, input1, receiver, name_conflicts_in_goScope, scope int
+		__scope.Declare("fn", "int", &fn, "ok", "int", &ok, "_ok", "int", &_ok, "ctx", "int", &ctx, "result1", "int", &result1, "input1", "int", &input1, "receiver", "int", &receiver, "name_conflicts_in_goScope", "int", &name_conflicts_in_goScope, "scope", "int", &scope)
 		_godebug.Line(_ctx, __scope, 15)
 		godebug.Println(fn, ok, _ok, ctx, result1, input1, receiver, name_conflicts_in_goScope, scope, _scope)
 	}
-	if _ctx, __ok := _godebug.EnterFuncLit(fn); __ok {
+	if _ctx, __ok := _godebug.EnterFuncLit("main.glob..func1", "main/name-conflicts-in.go", fn); __ok {
 		defer _godebug.ExitFunc(_ctx)
 		fn(_ctx)
+		_godebug.Returning(_ctx)
 	}
 }
 
 var _scope = 7
 
 func main() {
-	_ctx, __ok := _godebug.EnterFunc(main)
+	_ctx, __ok := _godebug.EnterFunc("main.main", "main/name-conflicts-in.go", main)
 	if !__ok {
 		return
 	}
+	defer _godebug.ExitFunc(_ctx)
 	_godebug.Line(_ctx, name_conflicts_in_go_scope, 21)
 	f()
 	_godebug.Line(_ctx, name_conflicts_in_go_scope, 22)
 	foo := "hello"
 	__scope := name_conflicts_in_go_scope.EnteringNewChildScope()
-	__scope.Declare("foo", &foo)
+	__scope.Declare("foo", "string", &foo)
 	{
 		_godebug.Line(_ctx, __scope, 24)
 		scope := 3
 		__scope := __scope.EnteringNewChildScope()
-		__scope.Declare("scope", &scope)
+		__scope.Declare("scope", "int", &scope)
 		{
 			_godebug.Line(_ctx, __scope, 26)
 			godebug.Println(2)
@@ -72,6 +78,7 @@ func main() {
 	}
 	_godebug.Line(_ctx, __scope, 30)
 	godebug.Println(foo)
+	_godebug.Returning(_ctx)
 }
 
 var name_conflicts_in_go_contents = `package main
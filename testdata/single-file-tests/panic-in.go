@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+type stack []int
+
+func (s *stack) pop() int {
+	top := (*s)[len(*s)-1]
+	*s = (*s)[:len(*s)-1]
+	return top
+}
+
+func sum(s stack) (total int) {
+	for i := 0; i < 3; i++ {
+		total += s.pop()
+	}
+	return total
+}
+
+func safeSum(s stack) (total int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sum failed: %v", r)
+		}
+	}()
+	return sum(s), nil
+}
+
+func main() {
+	_ = "breakpoint"
+	fmt.Println(safeSum(stack{1, 2}))
+	fmt.Println(safeSum(stack{1, 2, 3}))
+	fmt.Println(safeSum(stack{1}))
+	fmt.Println(safeSum(stack{4}))
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var panic_in_go_scope = godebug.EnteringNewScope(panic_in_go_contents, "main/panic-in.go", 30)
+var _ = godebug.DeclareTypes("main", "stack", `type stack []int
+
+func (s *stack) pop() int`)
+
+type stack []int
+
+func (s *stack) pop() (result1 int) {
+	ctx, ok := godebug.EnterFunc("main.(*stack).pop", "main/panic-in.go", func() {
+		result1 = s.pop()
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := panic_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("s", "*stack", &s)
+	godebug.Line(ctx, scope, 8)
+	top := (*s)[len(*s)-1]
+	scope.Declare("top", "int", &top)
+	godebug.Line(ctx, scope, 9)
+	*s = (*s)[:len(*s)-1]
+	godebug.Line(ctx, scope, 10)
+	godebug.Returning(ctx)
+	return top
+}
+
+func sum(s stack) (total int) {
+	ctx, ok := godebug.EnterFunc("main.sum", "main/panic-in.go", func() {
+		total = sum(s)
+	})
+	if !ok {
+		return total
+	}
+	defer godebug.ExitFunc(ctx, &total)
+	scope := panic_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("s", "stack", &s)
+	scope.Declare("total", "int", &total)
+	{
+		scope := scope.EnteringNewChildScope()
+		for i := 0; i < 3; i++ {
+			godebug.Line(ctx, scope, 14)
+			scope.Declare("i", "int", &i)
+			godebug.Line(ctx, scope, 15)
+			total += s.pop()
+		}
+		godebug.Line(ctx, scope, 14)
+	}
+	godebug.Line(ctx, scope, 17)
+	godebug.Returning(ctx)
+	return total
+}
+
+func safeSum(s stack) (total int, err error) {
+	ctx, ok := godebug.EnterFunc("main.safeSum", "main/panic-in.go", func() {
+		total, err = safeSum(s)
+	})
+	if !ok {
+		return total, err
+	}
+	defer godebug.ExitFunc(ctx, &total, &err)
+	scope := panic_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("s", "stack", &s)
+	scope.Declare("total", "int", &total, "err", "error", &err)
+	godebug.Line(ctx, scope, 21)
+	defer func() {
+		_r := make(chan chan interface {
+		})
+		recovers, panicChan := godebug.EnterFuncWithRecovers("main.safeSum.func1", "main/panic-in.go", _r, func(ctx *godebug.Context) {
+			scope := scope.EnteringNewChildScope()
+			scope.DeclareArgs()
+			godebug.Line(ctx, scope, 22)
+			if r := <-(<-_r); r != nil {
+				scope := scope.EnteringNewChildScope()
+				scope.Declare("r", "interface{}", &r)
+				godebug.Line(ctx, scope, 23)
+				err = fmt.Errorf("sum failed: %v", r)
+			}
+		})
+		for rr := range recovers {
+			rr <- recover()
+		}
+		if v, ok := <-panicChan; ok {
+			panic(v)
+		}
+	}()
+	defer godebug.Defer(ctx, scope, 21)
+	godebug.Line(ctx, scope, 26)
+	return sum(s), nil
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/panic-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, panic_in_go_scope, 30)
+	godebug.Line(ctx, panic_in_go_scope, 31)
+
+	fmt.Println(safeSum(stack{1, 2}))
+	godebug.Line(ctx, panic_in_go_scope, 32)
+	fmt.Println(safeSum(stack{1, 2, 3}))
+	godebug.Line(ctx, panic_in_go_scope, 33)
+	fmt.Println(safeSum(stack{1}))
+	godebug.Line(ctx, panic_in_go_scope, 34)
+	fmt.Println(safeSum(stack{4}))
+	godebug.Returning(ctx)
+}
+
+var panic_in_go_contents = `package main
+
+import "fmt"
+
+type stack []int
+
+func (s *stack) pop() int {
+	top := (*s)[len(*s)-1]
+	*s = (*s)[:len(*s)-1]
+	return top
+}
+
+func sum(s stack) (total int) {
+	for i := 0; i < 3; i++ {
+		total += s.pop()
+	}
+	return total
+}
+
+func safeSum(s stack) (total int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sum failed: %v", r)
+		}
+	}()
+	return sum(s), nil
+}
+
+func main() {
+	_ = "breakpoint"
+	fmt.Println(safeSum(stack{1, 2}))
+	fmt.Println(safeSum(stack{1, 2, 3}))
+	fmt.Println(safeSum(stack{1}))
+	fmt.Println(safeSum(stack{4}))
+}
+`
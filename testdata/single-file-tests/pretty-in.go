@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type color int
+
+func (c color) String() string { return [...]string{"red", "green", "blue"}[c] }
+
+type node struct {
+	name string
+	next *node
+}
+
+type job struct {
+	ID       int
+	Owner    string
+	Tags     []string
+	Timeout  time.Duration
+	Started  time.Time
+	Color    color
+	Err      error
+	Extra    interface{}
+	Next     *job
+	callback func()
+}
+
+func main() {
+	a := &node{name: "a"}
+	b := &node{name: "b", next: a}
+	a.next = b
+	j := &job{
+		ID:      7,
+		Owner:   "ops",
+		Tags:    []string{"nightly", "slow"},
+		Timeout: 1500 * time.Millisecond,
+		Started: time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC),
+		Color:   2,
+		Err:     errors.New("disk full"),
+		Extra:   3.0,
+		Next:    &job{ID: 8},
+	}
+	counts := map[string]int{"b": 2, "a": 1, "c": 3}
+	long := strings.Repeat("x", 300)
+	nums := make([]int, 150)
+	var missing *job
+	self := map[string]interface{}{"name": "self"}
+	self["self"] = self
+	ring := []interface{}{"ring", nil}
+	ring[1] = ring
+	_ = "breakpoint"
+	fmt.Println(a.name, j.ID, counts, len(long), len(nums), missing, len(self), len(ring))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"github.com/mailgun/godebug/lib"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var pretty_in_go_scope = godebug.EnteringNewScope(pretty_in_go_contents, "main/pretty-in.go", 55)
+var _ = godebug.DeclareTypes("main", "color", `type color int
+
+func (c color) String() string`, "job", `type job struct {
+    ID       int
+    Owner    string
+    Tags     []string
+    Timeout  time.Duration
+    Started  time.Time
+    Color    color
+    Err      error
+    Extra    interface{}
+    Next     *job
+    callback func()
+}`, "node", `type node struct {
+    name string
+    next *node
+}`)
+
+type color int
+
+func (c color) String() (result1 string) {
+	ctx, ok := godebug.EnterFunc("main.color.String", "main/pretty-in.go", func() {
+		result1 = c.String()
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := pretty_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("c", "color", &c)
+	godebug.Line(ctx, scope, 12)
+	return [...]string{"red", "green", "blue"}[c]
+}
+
+type node struct {
+	name string
+	next *node
+}
+
+type job struct {
+	ID       int
+	Owner    string
+	Tags     []string
+	Timeout  time.Duration
+	Started  time.Time
+	Color    color
+	Err      error
+	Extra    interface{}
+	Next     *job
+	callback func()
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/pretty-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, pretty_in_go_scope, 33)
+	a := &node{name: "a"}
+	scope := pretty_in_go_scope.EnteringNewChildScope()
+	scope.Declare("a", "*node", &a)
+	godebug.Line(ctx, scope, 34)
+	b := &node{name: "b", next: a}
+	scope.Declare("b", "*node", &b)
+	godebug.Line(ctx, scope, 35)
+	a.next = b
+	godebug.Line(ctx, scope, 36)
+	j := &job{
+		ID:      7,
+		Owner:   "ops",
+		Tags:    []string{"nightly", "slow"},
+		Timeout: 1500 * time.Millisecond,
+		Started: time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC),
+		Color:   2,
+		Err:     errors.New("disk full"),
+		Extra:   3.0,
+		Next:    &job{ID: 8},
+	}
+	scope.Declare("j", "*job", &j)
+	godebug.Line(ctx, scope, 47)
+
+	counts := map[string]int{"b": 2, "a": 1, "c": 3}
+	scope.Declare("counts", "map[string]int", &counts)
+	godebug.Line(ctx, scope, 48)
+	long := strings.Repeat("x", 300)
+	scope.Declare("long", "string", &long)
+	godebug.Line(ctx, scope, 49)
+	nums := make([]int, 150)
+	scope.Declare("nums", "[]int", &nums)
+	godebug.Line(ctx, scope, 50)
+	var missing *job
+	scope.Declare("missing", "*job", &missing)
+	godebug.Line(ctx, scope, 51)
+	self := map[string]interface{}{"name": "self"}
+	scope.Declare("self", "map[string]interface{}", &self)
+	godebug.Line(ctx, scope, 52)
+	self["self"] = self
+	godebug.Line(ctx, scope, 53)
+	ring := []interface{}{"ring", nil}
+	scope.Declare("ring", "[]interface{}", &ring)
+	godebug.Line(ctx, scope, 54)
+	ring[1] = ring
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 55)
+	godebug.Line(ctx, scope, 56)
+
+	fmt.Println(a.name, j.ID, counts, len(long), len(nums), missing, len(self), len(ring))
+	godebug.Returning(ctx)
+}
+
+var pretty_in_go_contents = `package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type color int
+
+func (c color) String() string { return [...]string{"red", "green", "blue"}[c] }
+
+type node struct {
+	name string
+	next *node
+}
+
+type job struct {
+	ID       int
+	Owner    string
+	Tags     []string
+	Timeout  time.Duration
+	Started  time.Time
+	Color    color
+	Err      error
+	Extra    interface{}
+	Next     *job
+	callback func()
+}
+
+func main() {
+	a := &node{name: "a"}
+	b := &node{name: "b", next: a}
+	a.next = b
+	j := &job{
+		ID:      7,
+		Owner:   "ops",
+		Tags:    []string{"nightly", "slow"},
+		Timeout: 1500 * time.Millisecond,
+		Started: time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC),
+		Color:   2,
+		Err:     errors.New("disk full"),
+		Extra:   3.0,
+		Next:    &job{ID: 8},
+	}
+	counts := map[string]int{"b": 2, "a": 1, "c": 3}
+	long := strings.Repeat("x", 300)
+	nums := make([]int, 150)
+	var missing *job
+	self := map[string]interface{}{"name": "self"}
+	self["self"] = self
+	ring := []interface{}{"ring", nil}
+	ring[1] = ring
+	_ = "breakpoint"
+	fmt.Println(a.name, j.ID, counts, len(long), len(nums), missing, len(self), len(ring))
+}
+`
@@ -5,12 +5,12 @@ import (
 	"github.com/mailgun/godebug/lib"
 )
 
-var recover_in_go_scope = godebug.EnteringNewScope(recover_in_go_contents)
+var recover_in_go_scope = godebug.EnteringNewScope(recover_in_go_contents, "main/recover-in.go", 48)
 
 func r1() {
 	_r := make(chan chan interface {
 	})
-	recovers, panicChan := godebug.EnterFuncWithRecovers(_r, func(ctx *godebug.Context) {
+	recovers, panicChan := godebug.EnterFuncWithRecovers("main.r1", "main/recover-in.go", _r, func(ctx *godebug.Context) {
 		godebug.Line(ctx, recover_in_go_scope, 6)
 		<-(<-_r)
 	})
@@ -25,20 +25,20 @@ func r1() {
 func r2() {
 	_r := make(chan chan interface {
 	})
-	recovers, panicChan := godebug.EnterFuncWithRecovers(_r, func(ctx *godebug.Context) {
+	recovers, panicChan := godebug.EnterFuncWithRecovers("main.r2", "main/recover-in.go", _r, func(ctx *godebug.Context) {
 		godebug.Line(ctx, recover_in_go_scope, 10)
 		if r := <-(<-_r); r == nil {
 			scope := recover_in_go_scope.EnteringNewChildScope()
-			scope.Declare("r", &r)
+			scope.Declare("r", "interface{}", &r)
 			godebug.Line(ctx, scope, 11)
-			log.Fatal("r2: Expected panic, but it didn't happen.")
+			godebug.Fatal(ctx, log.Fatal, "r2: Expected panic, but it didn't happen.")
 		}
 		godebug.Line(ctx, recover_in_go_scope, 13)
 		if r := <-(<-_r); r != nil {
 			scope := recover_in_go_scope.EnteringNewChildScope()
-			scope.Declare("r", &r)
+			scope.Declare("r", "interface{}", &r)
 			godebug.Line(ctx, scope, 14)
-			log.Fatal("r2: Second recover should return nil.")
+			godebug.Fatal(ctx, log.Fatal, "r2: Second recover should return nil.")
 		}
 	})
 	for rr := range recovers {
@@ -52,8 +52,10 @@ func r2() {
 var r3 = func() {
 	_r := make(chan chan interface {
 	})
-	recovers, panicChan := godebug.EnterFuncWithRecovers(_r, func(ctx *godebug.Context) {
-		godebug.Line(ctx, recover_in_go_scope, 19)
+	recovers, panicChan := godebug.EnterFuncWithRecovers("main.glob..func1", "main/recover-in.go", _r, func(ctx *godebug.Context) {
+		scope := recover_in_go_scope.EnteringNewChildScope()
+		scope.DeclareArgs()
+		godebug.Line(ctx, scope, 19)
 		<-(<-_r)
 	})
 	for rr := range recovers {
@@ -67,20 +69,22 @@ var r3 = func() {
 var r4 = func() {
 	_r := make(chan chan interface {
 	})
-	recovers, panicChan := godebug.EnterFuncWithRecovers(_r, func(ctx *godebug.Context) {
-		godebug.Line(ctx, recover_in_go_scope, 23)
+	recovers, panicChan := godebug.EnterFuncWithRecovers("main.glob..func2", "main/recover-in.go", _r, func(ctx *godebug.Context) {
+		scope := recover_in_go_scope.EnteringNewChildScope()
+		scope.DeclareArgs()
+		godebug.Line(ctx, scope, 23)
 		if r := <-(<-_r); r == nil {
-			scope := recover_in_go_scope.EnteringNewChildScope()
-			scope.Declare("r", &r)
+			scope := scope.EnteringNewChildScope()
+			scope.Declare("r", "interface{}", &r)
 			godebug.Line(ctx, scope, 24)
-			log.Fatal("r4: Expected panic, but it didn't happen.")
+			godebug.Fatal(ctx, log.Fatal, "r4: Expected panic, but it didn't happen.")
 		}
-		godebug.Line(ctx, recover_in_go_scope, 26)
+		godebug.Line(ctx, scope, 26)
 		if r := <-(<-_r); r != nil {
-			scope := recover_in_go_scope.EnteringNewChildScope()
-			scope.Declare("r", &r)
+			scope := scope.EnteringNewChildScope()
+			scope.Declare("r", "interface{}", &r)
 			godebug.Line(ctx, scope, 27)
-			log.Fatal("r4: Second recover should return nil.")
+			godebug.Fatal(ctx, log.Fatal, "r4: Second recover should return nil.")
 		}
 	})
 	for rr := range recovers {
@@ -92,7 +96,7 @@ var r4 = func() {
 }
 
 func doPanic(recoverer func()) {
-	ctx, ok := godebug.EnterFunc(func() {
+	ctx, ok := godebug.EnterFunc("main.doPanic", "main/recover-in.go", func() {
 		doPanic(recoverer)
 	})
 	if !ok {
@@ -100,16 +104,17 @@ func doPanic(recoverer func()) {
 	}
 	defer godebug.ExitFunc(ctx)
 	scope := recover_in_go_scope.EnteringNewChildScope()
-	scope.Declare("recoverer", &recoverer)
+	scope.DeclareArgs("recoverer", "func()", &recoverer)
 	godebug.Line(ctx, scope, 32)
 	defer recoverer()
 	defer godebug.Defer(ctx, scope, 32)
 	godebug.Line(ctx, scope, 33)
 	panic("doPanic: panic")
+	godebug.Returning(ctx)
 }
 
 func doNestedRecover(recoverer func()) {
-	ctx, ok := godebug.EnterFunc(func() {
+	ctx, ok := godebug.EnterFunc("main.doNestedRecover", "main/recover-in.go", func() {
 		doNestedRecover(recoverer)
 	})
 	if !ok {
@@ -117,20 +122,22 @@ func doNestedRecover(recoverer func()) {
 	}
 	defer godebug.ExitFunc(ctx)
 	scope := recover_in_go_scope.EnteringNewChildScope()
-	scope.Declare("recoverer", &recoverer)
+	scope.DeclareArgs("recoverer", "func()", &recoverer)
 	godebug.Line(ctx, scope, 37)
 	defer func() {
 		_r := make(chan chan interface {
 		})
-		recovers, panicChan := godebug.EnterFuncWithRecovers(_r, func(ctx *godebug.Context) {
+		recovers, panicChan := godebug.EnterFuncWithRecovers("main.doNestedRecover.func1", "main/recover-in.go", _r, func(ctx *godebug.Context) {
+			scope := scope.EnteringNewChildScope()
+			scope.DeclareArgs()
 			godebug.Line(ctx, scope, 39)
 			recoverer()
 			godebug.Line(ctx, scope, 40)
 			if r := <-(<-_r); r == nil {
 				scope := scope.EnteringNewChildScope()
-				scope.Declare("r", &r)
+				scope.Declare("r", "interface{}", &r)
 				godebug.Line(ctx, scope, 41)
-				log.Fatal("doNestedRecover: Expected to still be panicking, but we aren't.")
+				godebug.Fatal(ctx, log.Fatal, "doNestedRecover: Expected to still be panicking, but we aren't.")
 			}
 		})
 		for rr := range recovers {
@@ -143,13 +150,15 @@ func doNestedRecover(recoverer func()) {
 	defer godebug.Defer(ctx, scope, 37)
 	godebug.Line(ctx, scope, 44)
 	panic("doNestedRecover: panic")
+	godebug.Returning(ctx)
 }
 
 func main() {
-	ctx, ok := godebug.EnterFunc(main)
+	ctx, ok := godebug.EnterFunc("main.main", "main/recover-in.go", main)
 	if !ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.SetTraceGen(ctx)
 	godebug.Line(ctx, recover_in_go_scope, 48)
 	godebug.Line(ctx, recover_in_go_scope, 49)
@@ -171,22 +180,23 @@ func main() {
 	godebug.Line(ctx, recover_in_go_scope, 58)
 
 	doNestedPanic()
+	godebug.Returning(ctx)
 }
 
 func recovererWithParams(i int, s string) bool {
 	var result1 bool
 	_r := make(chan chan interface {
 	})
-	recovers, panicChan := godebug.EnterFuncWithRecovers(_r, func(ctx *godebug.Context) {
+	recovers, panicChan := godebug.EnterFuncWithRecovers("main.recovererWithParams", "main/recover-in.go", _r, func(ctx *godebug.Context) {
 		result1 = func() bool {
 			scope := recover_in_go_scope.EnteringNewChildScope()
-			scope.Declare("i", &i, "s", &s)
+			scope.DeclareArgs("i", "int", &i, "s", "string", &s)
 			godebug.Line(ctx, scope, 62)
 			<-(<-_r)
 			godebug.Line(ctx, scope, 63)
 			return true
 		}()
-	})
+	}, &result1)
 	for rr := range recovers {
 		rr <- recover()
 	}
@@ -197,7 +207,7 @@ func recovererWithParams(i int, s string) bool {
 }
 
 func doNestedPanic() {
-	ctx, ok := godebug.EnterFunc(doNestedPanic)
+	ctx, ok := godebug.EnterFunc("main.doNestedPanic", "main/recover-in.go", doNestedPanic)
 	if !ok {
 		return
 	}
@@ -206,8 +216,10 @@ func doNestedPanic() {
 	defer func() {
 		_r := make(chan chan interface {
 		})
-		recovers, panicChan := godebug.EnterFuncWithRecovers(_r, func(ctx *godebug.Context) {
-			godebug.Line(ctx, recover_in_go_scope, 68)
+		recovers, panicChan := godebug.EnterFuncWithRecovers("main.doNestedPanic.func1", "main/recover-in.go", _r, func(ctx *godebug.Context) {
+			scope := recover_in_go_scope.EnteringNewChildScope()
+			scope.DeclareArgs()
+			godebug.Line(ctx, scope, 68)
 			<-(<-_r)
 		})
 		for rr := range recovers {
@@ -220,12 +232,13 @@ func doNestedPanic() {
 	defer godebug.Defer(ctx, recover_in_go_scope, 67)
 	godebug.Line(ctx, recover_in_go_scope, 70)
 	recoverThenPanic()
+	godebug.Returning(ctx)
 }
 
 func recoverThenPanic() {
 	_r := make(chan chan interface {
 	})
-	recovers, panicChan := godebug.EnterFuncWithRecovers(_r, func(ctx *godebug.Context) {
+	recovers, panicChan := godebug.EnterFuncWithRecovers("main.recoverThenPanic", "main/recover-in.go", _r, func(ctx *godebug.Context) {
 		godebug.Line(ctx, recover_in_go_scope, 74)
 		<-(<-_r)
 		godebug.Line(ctx, recover_in_go_scope, 75)
@@ -2,13 +2,17 @@ package main
 
 import "github.com/mailgun/godebug/lib"
 
-var regression_in_go_scope = godebug.EnteringNewScope(regression_in_go_contents)
+var regression_in_go_scope = godebug.EnteringNewScope(regression_in_go_contents, "main/regression-in.go", 40, 132)
+var _ = godebug.DeclareTypes("main", "T", `type T struct{}
+
+func (name3 T) name3()`)
 
 func main() {
-	ctx, _ok := godebug.EnterFunc(main)
+	ctx, _ok := godebug.EnterFunc("main.main", "main/regression-in.go", main)
 	if !_ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.Line(ctx, regression_in_go_scope, 5)
 
 	foo := func(i int) int {
@@ -16,19 +20,20 @@ func main() {
 		fn := func(ctx *godebug.Context) {
 			result1 = func() int {
 				scope := regression_in_go_scope.EnteringNewChildScope()
-				scope.Declare("i", &i)
+				scope.DeclareArgs("i", "int", &i)
 				godebug.Line(ctx, scope, 6)
 				return i
 			}()
 		}
-		if ctx, _ok := godebug.EnterFuncLit(fn); _ok {
-			defer godebug.ExitFunc(ctx)
+		if ctx, _ok := godebug.EnterFuncLit("main.main.func1", "main/regression-in.go", fn); _ok {
+			defer godebug.ExitFunc(ctx, &result1)
 			fn(ctx)
+			godebug.Returning(ctx)
 		}
 		return result1
 	}(3)
 	scope := regression_in_go_scope.EnteringNewChildScope()
-	scope.Declare("foo", &foo)
+	scope.Declare("foo", "int", &foo)
 	godebug.Line(ctx, scope, 8)
 
 	_ = foo
@@ -37,7 +42,7 @@ func main() {
 
 		for _, s := range []string{"foo"} {
 			godebug.Line(ctx, scope, 12)
-			scope.Declare("s", &s)
+			scope.Declare("s", "string", &s)
 			godebug.Line(ctx, scope, 13)
 			_ = s
 		}
@@ -46,16 +51,19 @@ func main() {
 	godebug.Line(ctx, scope, 17)
 
 	c := make(chan bool)
-	scope.Declare("c", &c)
+	scope.Declare("c", "chan bool", &c)
 	godebug.Line(ctx, scope, 18)
 	go func() {
 		fn := func(ctx *godebug.Context) {
+			scope := scope.EnteringNewChildScope()
+			scope.DeclareArgs()
 			godebug.Line(ctx, scope, 19)
 			c <- true
 		}
-		if ctx, _ok := godebug.EnterFuncLit(fn); _ok {
+		if ctx, _ok := godebug.EnterFuncLit("main.main.func2", "main/regression-in.go", fn); _ok {
 			defer godebug.ExitFunc(ctx)
 			fn(ctx)
+			godebug.Returning(ctx)
 		}
 	}()
 	godebug.Line(ctx, scope, 21)
@@ -79,7 +87,7 @@ func main() {
 	godebug.Line(ctx, scope, 33)
 
 	m := map[string]int{"test": 5}
-	scope.Declare("m", &m)
+	scope.Declare("m", "map[string]int", &m)
 	godebug.Line(ctx, scope, 34)
 	if false {
 	} else {
@@ -96,7 +104,7 @@ func main() {
 	godebug.Line(ctx, scope, 41)
 
 	const n = 10
-	scope.Constant("n", n)
+	scope.Constant("n", "untyped int", n)
 	godebug.Line(ctx, scope, 42)
 	_ = n
 	godebug.Line(ctx, scope, 44)
@@ -106,17 +114,17 @@ func main() {
 	name2()
 	godebug.Line(ctx, scope, 46)
 	T{}.name3()
+	godebug.Returning(ctx)
 }
 
-func _switch() int {
-	var result1 int
-	ctx, _ok := godebug.EnterFunc(func() {
+func _switch() (result1 int) {
+	ctx, _ok := godebug.EnterFunc("main._switch", "main/regression-in.go", func() {
 		result1 = _switch()
 	})
 	if !_ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	godebug.Line(ctx, regression_in_go_scope, 51)
 
 	switch {
@@ -124,23 +132,24 @@ func _switch() int {
 		fallthrough
 	case false:
 		godebug.Line(ctx, regression_in_go_scope, 53)
+		godebug.Returning(ctx)
 		return 4
 	default:
 		godebug.Line(ctx, regression_in_go_scope, 54)
 		godebug.Line(ctx, regression_in_go_scope, 55)
+		godebug.Returning(ctx)
 		return 5
 	}
 }
 
-func _select() int {
-	var result1 int
-	ctx, _ok := godebug.EnterFunc(func() {
+func _select() (result1 int) {
+	ctx, _ok := godebug.EnterFunc("main._select", "main/regression-in.go", func() {
 		result1 = _select()
 	})
 	if !_ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	godebug.Select(ctx, regression_in_go_scope, 61)
 
 	select {
@@ -149,10 +158,12 @@ func _select() int {
 	case <-make(chan bool):
 		godebug.Line(ctx, regression_in_go_scope, 62)
 		godebug.Line(ctx, regression_in_go_scope, 63)
+		godebug.Returning(ctx)
 		return 4
 	default:
 		godebug.Line(ctx, regression_in_go_scope, 64)
 		godebug.Line(ctx, regression_in_go_scope, 65)
+		godebug.Returning(ctx)
 		return 5
 	case <-godebug.EndSelect(ctx, regression_in_go_scope):
 		panic("impossible")
@@ -160,7 +171,7 @@ func _select() int {
 }
 
 func name1(_name1 int) {
-	ctx, _ok := godebug.EnterFunc(func() {
+	ctx, _ok := godebug.EnterFunc("main.name1", "main/regression-in.go", func() {
 		name1(_name1)
 	})
 	if !_ok {
@@ -168,57 +179,61 @@ func name1(_name1 int) {
 	}
 	defer godebug.ExitFunc(ctx)
 	scope := regression_in_go_scope.EnteringNewChildScope()
-	scope.Declare("name1", &_name1)
+	scope.DeclareArgs("name1", "int", &_name1)
 	godebug.Line(ctx, scope, 71)
 	if true {
 		godebug.Line(ctx, scope, 72)
 		_ = _name1
 	}
+	godebug.Returning(ctx)
 }
 
 func name2() (_name2 string) {
-	ctx, _ok := godebug.EnterFunc(func() {
+	ctx, _ok := godebug.EnterFunc("main.name2", "main/regression-in.go", func() {
 		_name2 = name2()
 	})
 	if !_ok {
 		return _name2
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &_name2)
 	scope := regression_in_go_scope.EnteringNewChildScope()
-	scope.Declare("name2", &_name2)
+	scope.Declare("name2", "string", &_name2)
 	godebug.Line(ctx, scope, 78)
 	if true {
 		godebug.Line(ctx, scope, 79)
 		_name2 = "foo"
 	}
 	godebug.Line(ctx, scope, 81)
+	godebug.Returning(ctx)
 	return _name2
 }
 
 type T struct{}
 
 func (_name3 T) name3() {
-	ctx, _ok := godebug.EnterFunc(_name3.name3)
+	ctx, _ok := godebug.EnterFunc("main.T.name3", "main/regression-in.go", _name3.name3)
 	if !_ok {
 		return
 	}
 	defer godebug.ExitFunc(ctx)
 	scope := regression_in_go_scope.EnteringNewChildScope()
-	scope.Declare("name3", &_name3)
+	scope.DeclareArgs("name3", "T", &_name3)
 	godebug.Line(ctx, scope, 88)
 	if true {
 		godebug.Line(ctx, scope, 89)
 		_ = _name3
 	}
+	godebug.Returning(ctx)
 }
 
 var nestedSwitch = func() {
 	fn := func(ctx *godebug.Context) {
-		godebug.Line(ctx, regression_in_go_scope, 94)
+		scope := regression_in_go_scope.EnteringNewChildScope()
+		scope.DeclareArgs()
+		godebug.Line(ctx, scope, 94)
 		var foo interface {
 		} = 5
-		scope := regression_in_go_scope.EnteringNewChildScope()
-		scope.Declare("foo", &foo)
+		scope.Declare("foo", "interface{}", &foo)
 		godebug.Line(ctx, scope, 96)
 		switch {
 		default:
@@ -230,9 +245,10 @@ var nestedSwitch = func() {
 			}
 		}
 	}
-	if ctx, _ok := godebug.EnterFuncLit(fn); _ok {
+	if ctx, _ok := godebug.EnterFuncLit("main.glob..func1", "main/regression-in.go", fn); _ok {
 		defer godebug.ExitFunc(ctx)
 		fn(ctx)
+		godebug.Returning(ctx)
 	}
 }
 
@@ -241,7 +257,7 @@ func init() {
 }
 
 func doFallthrough() {
-	ctx, _ok := godebug.EnterFunc(doFallthrough)
+	ctx, _ok := godebug.EnterFunc("main.doFallthrough", "main/regression-in.go", doFallthrough)
 	if !_ok {
 		return
 	}
@@ -249,7 +265,7 @@ func doFallthrough() {
 	godebug.Line(ctx, regression_in_go_scope, 110)
 	fellthrough := false
 	scope := regression_in_go_scope.EnteringNewChildScope()
-	scope.Declare("fellthrough", &fellthrough)
+	scope.Declare("fellthrough", "bool", &fellthrough)
 	godebug.Line(ctx, scope, 111)
 	switch {
 	case godebug.Case(ctx, scope, 112):
@@ -268,18 +284,19 @@ func doFallthrough() {
 		godebug.Line(ctx, scope, 118)
 		panic("fallthrough statement did not work")
 	}
+	godebug.Returning(ctx)
 }
 
-func a() int {
-	var result1 int
-	ctx, _ok := godebug.EnterFunc(func() {
+func a() (result1 int) {
+	ctx, _ok := godebug.EnterFunc("main.a", "main/regression-in.go", func() {
 		result1 = a()
 	})
 	if !_ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	godebug.Line(ctx, regression_in_go_scope, 123)
+	godebug.Returning(ctx)
 	return 0
 }
 
@@ -288,7 +305,7 @@ func init() {
 }
 
 func switchInit() {
-	ctx, _ok := godebug.EnterFunc(switchInit)
+	ctx, _ok := godebug.EnterFunc("main.switchInit", "main/regression-in.go", switchInit)
 	if !_ok {
 		return
 	}
@@ -299,7 +316,7 @@ func switchInit() {
 		godebug.Line(ctx, regression_in_go_scope, 133)
 		a := a()
 		scope := regression_in_go_scope.EnteringNewChildScope()
-		scope.Declare("a", &a)
+		scope.Declare("a", "int", &a)
 		switch {
 		default:
 			godebug.Line(ctx, scope, 134)
@@ -309,6 +326,7 @@ func switchInit() {
 	}
 	godebug.Line(ctx, regression_in_go_scope, 137)
 	_ = "the variable a should be out of scope"
+	godebug.Returning(ctx)
 }
 
 var regression_in_go_contents = `package main
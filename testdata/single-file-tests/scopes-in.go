@@ -0,0 +1,30 @@
+package main
+
+import "fmt"
+
+type counter struct {
+	name string
+	n    int
+}
+
+func (c *counter) add(delta int, label string) (total int) {
+	const step = 1
+	x := c.n
+	for i := 0; i < delta; i += step {
+		x := x + i
+		_ = "breakpoint"
+		total = x
+	}
+	c.n = total
+	return total
+}
+
+func main() {
+	c := &counter{name: "c"}
+	c.add(2, "first")
+	func() {
+		msg := "closure"
+		_ = "breakpoint"
+		fmt.Println(msg, c.n)
+	}()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var scopes_in_go_scope = godebug.EnteringNewScope(scopes_in_go_contents, "main/scopes-in.go", 15, 27)
+var _ = godebug.DeclareTypes("main", "counter", `type counter struct {
+    name string
+    n    int
+}
+
+func (c *counter) add(delta int, label string) (total int)`)
+
+type counter struct {
+	name string
+	n    int
+}
+
+func (c *counter) add(delta int, label string) (total int) {
+	ctx, ok := godebug.EnterFunc("main.(*counter).add", "main/scopes-in.go", func() {
+		total = c.add(delta, label)
+	})
+	if !ok {
+		return total
+	}
+	defer godebug.ExitFunc(ctx, &total)
+	scope := scopes_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("c", "*counter", &c, "delta", "int", &delta, "label", "string", &label)
+	scope.Declare("total", "int", &total)
+	godebug.Line(ctx, scope, 11)
+	const step = 1
+	scope.Constant("step", "untyped int", step)
+	godebug.Line(ctx, scope, 12)
+	x := c.n
+	scope.Declare("x", "int", &x)
+	{
+		scope := scope.EnteringNewChildScope()
+		for i := 0; i < delta; i += step {
+			godebug.Line(ctx, scope, 13)
+			scope.Declare("i", "int", &i)
+			godebug.Line(ctx, scope, 14)
+			x := x + i
+			scope := scope.EnteringNewChildScope()
+			scope.Declare("x", "int", &x)
+			godebug.SetTraceGen(ctx)
+			godebug.Line(ctx, scope, 15)
+			godebug.Line(ctx, scope, 16)
+
+			total = x
+		}
+		godebug.Line(ctx, scope, 13)
+	}
+	godebug.Line(ctx, scope, 18)
+	c.n = total
+	godebug.Line(ctx, scope, 19)
+	godebug.Returning(ctx)
+	return total
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/scopes-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, scopes_in_go_scope, 23)
+	c := &counter{name: "c"}
+	scope := scopes_in_go_scope.EnteringNewChildScope()
+	scope.Declare("c", "*counter", &c)
+	godebug.Line(ctx, scope, 24)
+	c.add(2, "first")
+	godebug.Line(ctx, scope, 25)
+	func() {
+		fn := func(ctx *godebug.Context) {
+			scope := scope.EnteringNewChildScope()
+			scope.DeclareArgs()
+			godebug.Line(ctx, scope, 26)
+			msg := "closure"
+			scope.Declare("msg", "string", &msg)
+			godebug.SetTraceGen(ctx)
+			godebug.Line(ctx, scope, 27)
+			godebug.Line(ctx, scope, 28)
+			fmt.Println(msg, c.n)
+		}
+		if ctx, ok := godebug.EnterFuncLit("main.main.func1", "main/scopes-in.go", fn); ok {
+			defer godebug.ExitFunc(ctx)
+			fn(ctx)
+			godebug.Returning(ctx)
+		}
+	}()
+	godebug.Returning(ctx)
+}
+
+var scopes_in_go_contents = `package main
+
+import "fmt"
+
+type counter struct {
+	name string
+	n    int
+}
+
+func (c *counter) add(delta int, label string) (total int) {
+	const step = 1
+	x := c.n
+	for i := 0; i < delta; i += step {
+		x := x + i
+		_ = "breakpoint"
+		total = x
+	}
+	c.n = total
+	return total
+}
+
+func main() {
+	c := &counter{name: "c"}
+	c.add(2, "first")
+	func() {
+		msg := "closure"
+		_ = "breakpoint"
+		fmt.Println(msg, c.n)
+	}()
+}
+`
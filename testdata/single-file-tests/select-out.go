@@ -5,48 +5,48 @@ import (
 	"github.com/mailgun/godebug/lib"
 )
 
-var select_in_go_scope = godebug.EnteringNewScope(select_in_go_contents)
+var select_in_go_scope = godebug.EnteringNewScope(select_in_go_contents, "main/select-in.go", 24)
 
-func foo() chan int {
-	var result1 chan int
-	ctx, _ok := godebug.EnterFunc(func() {
+func foo() (result1 chan int) {
+	ctx, _ok := godebug.EnterFunc("main.foo", "main/select-in.go", func() {
 		result1 = foo()
 	})
 	if !_ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	godebug.Line(ctx, select_in_go_scope, 6)
 	return make(chan int)
 }
 
-func bar() int {
-	var result1 int
-	ctx, _ok := godebug.EnterFunc(func() {
+func bar() (result1 int) {
+	ctx, _ok := godebug.EnterFunc("main.bar", "main/select-in.go", func() {
 		result1 = bar()
 	})
 	if !_ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	godebug.Line(ctx, select_in_go_scope, 10)
+	godebug.Returning(ctx)
 	return 0
 }
 
 func main() {
-	ctx, _ok := godebug.EnterFunc(main)
+	ctx, _ok := godebug.EnterFunc("main.main", "main/select-in.go", main)
 	if !_ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.Line(ctx, select_in_go_scope, 14)
 	c := make([]chan int, 10)
 	scope := select_in_go_scope.EnteringNewChildScope()
-	scope.Declare("c", &c)
+	scope.Declare("c", "[]chan int", &c)
 	{
 		scope := scope.EnteringNewChildScope()
 		for i := range c {
 			godebug.Line(ctx, scope, 15)
-			scope.Declare("i", &i)
+			scope.Declare("i", "int", &i)
 			godebug.Line(ctx, scope, 16)
 			c[i] = make(chan int, 1)
 		}
@@ -55,10 +55,10 @@ func main() {
 	godebug.Line(ctx, scope, 19)
 
 	var r1 int
-	scope.Declare("r1", &r1)
+	scope.Declare("r1", "int", &r1)
 	godebug.Line(ctx, scope, 20)
 	var ok bool
-	scope.Declare("ok", &ok)
+	scope.Declare("ok", "bool", &ok)
 	godebug.Line(ctx, scope, 22)
 
 	_, _ = r1, ok
@@ -68,15 +68,18 @@ func main() {
 
 	go func() {
 		fn := func(ctx *godebug.Context) {
+			scope := scope.EnteringNewChildScope()
+			scope.DeclareArgs()
 			godebug.Select(ctx, scope, 30)
 			select {
 			case <-godebug.EndSelect(ctx, scope):
 				panic("impossible")
 			}
 		}
-		if ctx, _ok := godebug.EnterFuncLit(fn); _ok {
+		if ctx, _ok := godebug.EnterFuncLit("main.main.func1", "main/select-in.go", fn); _ok {
 			defer godebug.ExitFunc(ctx)
 			fn(ctx)
+			godebug.Returning(ctx)
 		}
 	}()
 	godebug.Select(ctx, scope, 33)
@@ -112,7 +115,7 @@ func main() {
 		godebug.Line(ctx, scope, 49)
 		hi := "hello"
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("hi", &hi)
+		scope.Declare("hi", "string", &hi)
 		godebug.Line(ctx, scope, 50)
 		fmt.Println(hi)
 	default:
@@ -131,7 +134,7 @@ func main() {
 		godebug.Line(ctx, scope, 57)
 		hi := "hi"
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("hi", &hi)
+		scope.Declare("hi", "string", &hi)
 		godebug.Select(ctx, scope, 58)
 		select {
 		case <-godebug.Comm(ctx, scope, 59):
@@ -141,7 +144,7 @@ func main() {
 			godebug.Line(ctx, scope, 60)
 			hi := "hello"
 			scope := scope.EnteringNewChildScope()
-			scope.Declare("hi", &hi)
+			scope.Declare("hi", "string", &hi)
 			godebug.Line(ctx, scope, 61)
 			fmt.Println(hi)
 		default:
@@ -168,7 +171,7 @@ func main() {
 		godebug.Line(ctx, scope, 72)
 		hi := "hello"
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("hi", &hi)
+		scope.Declare("hi", "string", &hi)
 		godebug.Line(ctx, scope, 73)
 		fmt.Println(hi)
 	case <-godebug.Comm(ctx, scope, 74):
@@ -202,7 +205,7 @@ func main() {
 	case r2 := <-c[3]:
 		godebug.Line(ctx, scope, 87)
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("r2", &r2)
+		scope.Declare("r2", "int", &r2)
 		godebug.Line(ctx, scope, 88)
 		_ = r2
 	case <-godebug.Comm(ctx, scope, 90):
@@ -223,7 +226,7 @@ func main() {
 	case _, ok1 := <-c[7]:
 		godebug.Line(ctx, scope, 93)
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("ok1", &ok1)
+		scope.Declare("ok1", "bool", &ok1)
 		godebug.Line(ctx, scope, 94)
 		_ = ok1
 	case <-godebug.Comm(ctx, scope, 95):
@@ -235,7 +238,7 @@ func main() {
 	case r2, ok := <-c[9]:
 		godebug.Line(ctx, scope, 96)
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("r2", &r2, "ok", &ok)
+		scope.Declare("r2", "int", &r2, "ok", "bool", &ok)
 		godebug.Line(ctx, scope, 97)
 		_, _ = r2, ok
 	case <-godebug.Comm(ctx, scope, 99):
@@ -256,7 +259,7 @@ func main() {
 	case r2 := <-foo():
 		godebug.Line(ctx, scope, 102)
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("r2", &r2)
+		scope.Declare("r2", "int", &r2)
 		godebug.Line(ctx, scope, 103)
 		_ = r2
 	case <-godebug.Comm(ctx, scope, 105):
@@ -277,7 +280,7 @@ func main() {
 	case _, ok1 := <-foo():
 		godebug.Line(ctx, scope, 108)
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("ok1", &ok1)
+		scope.Declare("ok1", "bool", &ok1)
 		godebug.Line(ctx, scope, 109)
 		_ = ok1
 	case <-godebug.Comm(ctx, scope, 110):
@@ -289,7 +292,7 @@ func main() {
 	case r2, ok := <-foo():
 		godebug.Line(ctx, scope, 111)
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("r2", &r2, "ok", &ok)
+		scope.Declare("r2", "int", &r2, "ok", "bool", &ok)
 		godebug.Line(ctx, scope, 112)
 		_, _ = r2, ok
 	case <-godebug.EndSelect(ctx, scope):
@@ -303,12 +306,15 @@ func main() {
 
 	go func() {
 		fn := func(ctx *godebug.Context) {
+			scope := scope.EnteringNewChildScope()
+			scope.DeclareArgs()
 			godebug.Line(ctx, scope, 122)
 			<-c[1]
 		}
-		if ctx, _ok := godebug.EnterFuncLit(fn); _ok {
+		if ctx, _ok := godebug.EnterFuncLit("main.main.func2", "main/select-in.go", fn); _ok {
 			defer godebug.ExitFunc(ctx)
 			fn(ctx)
+			godebug.Returning(ctx)
 		}
 	}()
 	godebug.Select(ctx, scope, 125)
@@ -338,6 +344,7 @@ func main() {
 		panic("impossible")
 
 	}
+	godebug.Returning(ctx)
 }
 
 var select_in_go_contents = `package main
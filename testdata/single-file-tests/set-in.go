@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+type config struct {
+	Name    string
+	Timeout int
+	retries int
+}
+
+func main() {
+	const limit = 3
+	cfg := &config{Name: "default", Timeout: 10}
+	items := []interface{}{1, "two", 3.0, 4}
+	counts := map[string]int{"a": 1}
+	var grid [2]int
+	_ = "breakpoint"
+	fmt.Println(cfg.Name, cfg.Timeout, cfg.retries)
+	fmt.Println(items, counts, grid, limit)
+	cfg.retries++
+	items[2] = "three"
+	fmt.Println(cfg.retries, items[2])
+}
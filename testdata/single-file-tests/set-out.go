@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+)
+
+var set_in_go_scope = godebug.EnteringNewScope(set_in_go_contents, "main/set-in.go", 17)
+var _ = godebug.DeclareTypes("main", "config", `type config struct {
+    Name    string
+    Timeout int
+    retries int
+}`)
+
+type config struct {
+	Name    string
+	Timeout int
+	retries int
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/set-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, set_in_go_scope, 12)
+	const limit = 3
+	scope := set_in_go_scope.EnteringNewChildScope()
+	scope.Constant("limit", "untyped int", limit)
+	godebug.Line(ctx, scope, 13)
+	cfg := &config{Name: "default", Timeout: 10}
+	scope.Declare("cfg", "*config", &cfg)
+	godebug.Line(ctx, scope, 14)
+	items := []interface{}{1, "two", 3.0, 4}
+	scope.Declare("items", "[]interface{}", &items)
+	godebug.Line(ctx, scope, 15)
+	counts := map[string]int{"a": 1}
+	scope.Declare("counts", "map[string]int", &counts)
+	godebug.Line(ctx, scope, 16)
+	var grid [2]int
+	scope.Declare("grid", "[2]int", &grid)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 17)
+	godebug.Line(ctx, scope, 18)
+
+	fmt.Println(cfg.Name, cfg.Timeout, cfg.retries)
+	godebug.Line(ctx, scope, 19)
+	fmt.Println(items, counts, grid, limit)
+	godebug.Line(ctx, scope, 20)
+	cfg.retries++
+	godebug.Line(ctx, scope, 21)
+	items[2] = "three"
+	godebug.Line(ctx, scope, 22)
+	fmt.Println(cfg.retries, items[2])
+	godebug.Returning(ctx)
+}
+
+var set_in_go_contents = `package main
+
+import "fmt"
+
+type config struct {
+	Name    string
+	Timeout int
+	retries int
+}
+
+func main() {
+	const limit = 3
+	cfg := &config{Name: "default", Timeout: 10}
+	items := []interface{}{1, "two", 3.0, 4}
+	counts := map[string]int{"a": 1}
+	var grid [2]int
+	_ = "breakpoint"
+	fmt.Println(cfg.Name, cfg.Timeout, cfg.retries)
+	fmt.Println(items, counts, grid, limit)
+	cfg.retries++
+	items[2] = "three"
+	fmt.Println(cfg.retries, items[2])
+}
+`
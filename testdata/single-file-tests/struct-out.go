@@ -2,13 +2,14 @@ package main
 
 import "github.com/mailgun/godebug/lib"
 
-var struct_in_go_scope = godebug.EnteringNewScope(struct_in_go_contents)
+var struct_in_go_scope = godebug.EnteringNewScope(struct_in_go_contents, "main/struct-in.go", 11)
 
 func main() {
-	ctx, ok := godebug.EnterFunc(main)
+	ctx, ok := godebug.EnterFunc("main.main", "main/struct-in.go", main)
 	if !ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.Line(ctx, struct_in_go_scope, 4)
 	type myType struct {
 		A int
@@ -19,12 +20,13 @@ func main() {
 	godebug.Line(ctx, struct_in_go_scope, 10)
 	var v myType
 	scope := struct_in_go_scope.EnteringNewChildScope()
-	scope.Declare("v", &v)
+	scope.Declare("v", "myType", &v)
 	godebug.SetTraceGen(ctx)
 	godebug.Line(ctx, scope, 11)
 	godebug.Line(ctx, scope, 12)
 
 	_ = v
+	godebug.Returning(ctx)
 }
 
 var struct_in_go_contents = `package main
@@ -5,26 +5,27 @@ import (
 	"github.com/mailgun/godebug/lib"
 )
 
-var switch_in_go_scope = godebug.EnteringNewScope(switch_in_go_contents)
+var switch_in_go_scope = godebug.EnteringNewScope(switch_in_go_contents, "main/switch-in.go", 10)
 
-func foo() interface{} {
-	var result1 interface{}
-	ctx, ok := godebug.EnterFunc(func() {
+func foo() (result1 interface{}) {
+	ctx, ok := godebug.EnterFunc("main.foo", "main/switch-in.go", func() {
 		result1 = foo()
 	})
 	if !ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	godebug.Line(ctx, switch_in_go_scope, 6)
+	godebug.Returning(ctx)
 	return "hi"
 }
 
 func main() {
-	ctx, ok := godebug.EnterFunc(main)
+	ctx, ok := godebug.EnterFunc("main.main", "main/switch-in.go", main)
 	if !ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.SetTraceGen(ctx)
 	godebug.Line(ctx, switch_in_go_scope, 10)
 	godebug.Line(ctx, switch_in_go_scope, 12)
@@ -45,7 +46,7 @@ func main() {
 
 	i := 3
 	scope := switch_in_go_scope.EnteringNewChildScope()
-	scope.Declare("i", &i)
+	scope.Declare("i", "int", &i)
 	godebug.Line(ctx, scope, 21)
 
 	switch i {
@@ -64,7 +65,7 @@ func main() {
 	godebug.Line(ctx, scope, 28)
 
 	var ifc interface{} = i
-	scope.Declare("ifc", &ifc)
+	scope.Declare("ifc", "interface{}", &ifc)
 	godebug.Line(ctx, scope, 30)
 
 	switch ifc.(type) {
@@ -77,7 +78,7 @@ func main() {
 		godebug.Line(ctx, scope, 35)
 		b := 2
 		scope := scope.EnteringNewChildScope()
-		scope.Declare("b", &b)
+		scope.Declare("b", "int", &b)
 		switch b == 6 {
 		case godebug.Case(ctx, scope, 36):
 			fallthrough
@@ -99,6 +100,7 @@ func main() {
 		godebug.Line(ctx, scope, 44)
 		_, _ = i, b
 	}
+	godebug.Returning(ctx)
 }
 
 var switch_in_go_contents = `package main
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testCase struct {
+	a, b, want int
+}
+
+var cases = []testCase{{1, 2, 3}, {2, 2, 5}, {0, 0, 0}}
+
+func add(a, b int) int {
+	return a + b
+}
+
+func TestAdd(t *testing.T) {
+	for _, c := range cases {
+		if got := add(c.a, c.b); got != c.want {
+			t.Errorf("add(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+	if len(cases) > 10 {
+		t.Fatal("too many cases")
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, c := range cases {
+			if got := add(c.a, c.b); got != c.want {
+				b.Errorf("add(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+			}
+		}
+	}
+}
+
+func main() {
+	testing.Init()
+	_ = "breakpoint"
+	r := testing.Benchmark(BenchmarkAdd)
+	fmt.Println("ran:", r.N > 0)
+}
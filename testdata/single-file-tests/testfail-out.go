@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mailgun/godebug/lib"
+	"testing"
+)
+
+var testfail_in_go_scope = godebug.EnteringNewScope(testfail_in_go_contents, "main/testfail-in.go", 41)
+var _ = godebug.DeclareTypes("main", "testCase", `type testCase struct {
+    a    int
+    b    int
+    want int
+}`)
+
+type testCase struct {
+	a, b, want int
+}
+
+var cases = []testCase{{1, 2, 3}, {2, 2, 5}, {0, 0, 0}}
+
+func add(a, b int) (result1 int) {
+	ctx, ok := godebug.EnterFunc("main.add", "main/testfail-in.go", func() {
+		result1 = add(a, b)
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := testfail_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("a", "int", &a, "b", "int", &b)
+	godebug.Line(ctx, scope, 15)
+	godebug.Returning(ctx)
+	return a + b
+}
+
+func TestAdd(t *testing.T) {
+	ctx, ok := godebug.EnterFunc("main.TestAdd", "main/testfail-in.go", func() {
+		TestAdd(t)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := testfail_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("t", "*testing.T", &t)
+	{
+		scope := scope.EnteringNewChildScope()
+		for _, c := range cases {
+			godebug.Line(ctx, scope, 19)
+			scope.Declare("c", "testCase", &c)
+			godebug.Line(ctx, scope, 20)
+			if got := add(c.a, c.b); got != c.want {
+				scope := scope.EnteringNewChildScope()
+				scope.Declare("got", "int", &got)
+				godebug.TestFailure(ctx)
+				godebug.Line(ctx, scope, 21)
+				t.Errorf("add(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+			}
+		}
+		godebug.Line(ctx, scope, 19)
+	}
+	godebug.Line(ctx, scope, 24)
+	if len(cases) > 10 {
+		godebug.TestFailure(ctx)
+		godebug.Line(ctx, scope, 25)
+		t.Fatal("too many cases")
+	}
+	godebug.Returning(ctx)
+}
+
+func BenchmarkAdd(b *testing.B) {
+	ctx, ok := godebug.EnterFunc("main.BenchmarkAdd", "main/testfail-in.go", func() {
+		BenchmarkAdd(b)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := testfail_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("b", "*testing.B", &b)
+	{
+		scope := scope.EnteringNewChildScope()
+		for i := 0; i < b.N; i++ {
+			godebug.Line(ctx, scope, 30)
+			scope.Declare("i", "int", &i)
+			{
+				scope := scope.EnteringNewChildScope()
+				for _, c := range cases {
+					godebug.Line(ctx, scope, 31)
+					scope.Declare("c", "testCase", &c)
+					godebug.Line(ctx, scope, 32)
+					if got := add(c.a, c.b); got != c.want {
+						scope := scope.EnteringNewChildScope()
+						scope.Declare("got", "int", &got)
+						godebug.TestFailure(ctx)
+						godebug.Line(ctx, scope, 33)
+						b.Errorf("add(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+					}
+				}
+				godebug.Line(ctx, scope, 31)
+			}
+		}
+		godebug.Line(ctx, scope, 30)
+	}
+	godebug.Returning(ctx)
+}
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/testfail-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, testfail_in_go_scope, 40)
+	testing.Init()
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, testfail_in_go_scope, 41)
+	godebug.Line(ctx, testfail_in_go_scope, 42)
+
+	r := testing.Benchmark(BenchmarkAdd)
+	scope := testfail_in_go_scope.EnteringNewChildScope()
+	scope.Declare("r", "testing.BenchmarkResult", &r)
+	godebug.Line(ctx, scope, 43)
+	fmt.Println("ran:", r.N > 0)
+	godebug.Returning(ctx)
+}
+
+var testfail_in_go_contents = `package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testCase struct {
+	a, b, want int
+}
+
+var cases = []testCase{{1, 2, 3}, {2, 2, 5}, {0, 0, 0}}
+
+func add(a, b int) int {
+	return a + b
+}
+
+func TestAdd(t *testing.T) {
+	for _, c := range cases {
+		if got := add(c.a, c.b); got != c.want {
+			t.Errorf("add(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+	if len(cases) > 10 {
+		t.Fatal("too many cases")
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, c := range cases {
+			if got := add(c.a, c.b); got != c.want {
+				b.Errorf("add(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+			}
+		}
+	}
+}
+
+func main() {
+	testing.Init()
+	_ = "breakpoint"
+	r := testing.Benchmark(BenchmarkAdd)
+	fmt.Println("ran:", r.N > 0)
+}
+`
@@ -2,30 +2,31 @@ package main
 
 import "github.com/mailgun/godebug/lib"
 
-var unnamed_input_in_go_scope = godebug.EnteringNewScope(unnamed_input_in_go_contents)
+var unnamed_input_in_go_scope = godebug.EnteringNewScope(unnamed_input_in_go_contents, "main/unnamed_input-in.go")
 
 func main() {
-	ctx, ok := godebug.EnterFunc(main)
+	ctx, ok := godebug.EnterFunc("main.main", "main/unnamed_input-in.go", main)
 	if !ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.Line(ctx, unnamed_input_in_go_scope, 4)
 	foo(3, 3)
+	godebug.Returning(ctx)
 }
 
-func foo(int, int) (string, error) {
+func foo(int, int) (result1 string, result2 error) {
 	var input1 int
 	var input2 int
-	var result1 string
-	var result2 error
-	ctx, ok := godebug.EnterFunc(func() {
+	ctx, ok := godebug.EnterFunc("main.foo", "main/unnamed_input-in.go", func() {
 		result1, result2 = foo(input1, input2)
 	})
 	if !ok {
 		return result1, result2
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1, &result2)
 	godebug.Line(ctx, unnamed_input_in_go_scope, 8)
+	godebug.Returning(ctx)
 	return "hello", nil
 }
 
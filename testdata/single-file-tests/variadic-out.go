@@ -2,30 +2,32 @@ package main
 
 import "github.com/mailgun/godebug/lib"
 
-var variadic_in_go_scope = godebug.EnteringNewScope(variadic_in_go_contents)
+var variadic_in_go_scope = godebug.EnteringNewScope(variadic_in_go_contents, "main/variadic-in.go")
 
-func Varargs(i ...int) int {
-	var result1 int
-	ctx, ok := godebug.EnterFunc(func() {
+func Varargs(i ...int) (result1 int) {
+	ctx, ok := godebug.EnterFunc("main.Varargs", "main/variadic-in.go", func() {
 		result1 = Varargs(i...)
 	})
 	if !ok {
 		return result1
 	}
-	defer godebug.ExitFunc(ctx)
+	defer godebug.ExitFunc(ctx, &result1)
 	scope := variadic_in_go_scope.EnteringNewChildScope()
-	scope.Declare("i", &i)
+	scope.DeclareArgs("i", "[]int", &i)
 	godebug.Line(ctx, scope, 4)
+	godebug.Returning(ctx)
 	return 6
 }
 
 func main() {
-	ctx, ok := godebug.EnterFunc(main)
+	ctx, ok := godebug.EnterFunc("main.main", "main/variadic-in.go", main)
 	if !ok {
 		return
 	}
+	defer godebug.ExitFunc(ctx)
 	godebug.Line(ctx, variadic_in_go_scope, 8)
 	Varargs(1, 2, 3, 4)
+	godebug.Returning(ctx)
 }
 
 var variadic_in_go_contents = `package main
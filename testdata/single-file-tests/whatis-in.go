@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+type shape interface {
+	Area() float64
+	fmt.Stringer
+}
+
+type rect struct {
+	W, H    float64
+	Created time.Time
+	io.Reader
+}
+
+func (r rect) Area() float64 { return r.W * r.H }
+
+func (r rect) String() string { return "rect" }
+
+func (r *rect) Scale(by float64) { r.W, r.H = r.W*by, r.H*by }
+
+type celsius float64
+
+func main() {
+	const limit = 10
+	const freezing celsius = 0
+	var err error
+	other := errors.New("boom")
+	var s shape = rect{W: 2, H: 3}
+	b := byte('x')
+	r := &rect{W: 1, H: 1}
+	var parse func(string, ...*template.Template) (n int, err error)
+	var results chan<- map[string][]template.Template
+	_ = "breakpoint"
+	fmt.Println(limit, freezing, err, other, s, b, r, parse == nil, results == nil)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"github.com/mailgun/godebug/lib"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+var whatis_in_go_scope = godebug.EnteringNewScope(whatis_in_go_contents, "main/whatis-in.go", 40)
+var _ = godebug.DeclareTypes("main", "celsius", `type celsius float64`, "rect", `type rect struct {
+    W       float64
+    H       float64
+    Created time.Time
+    io.Reader
+}
+
+func (r rect) Area() float64
+func (r rect) String() string
+func (r *rect) Scale(by float64)`, "shape", `type shape interface {
+    fmt.Stringer
+    Area() float64
+}`)
+
+type shape interface {
+	Area() float64
+	fmt.Stringer
+}
+
+type rect struct {
+	W, H    float64
+	Created time.Time
+	io.Reader
+}
+
+func (r rect) Area() (result1 float64) {
+	ctx, ok := godebug.EnterFunc("main.rect.Area", "main/whatis-in.go", func() {
+		result1 = r.Area()
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := whatis_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("r", "rect", &r)
+	godebug.Line(ctx, scope, 22)
+	return r.W * r.H
+}
+
+func (r rect) String() (result1 string) {
+	ctx, ok := godebug.EnterFunc("main.rect.String", "main/whatis-in.go", func() {
+		result1 = r.String()
+	})
+	if !ok {
+		return result1
+	}
+	defer godebug.ExitFunc(ctx, &result1)
+	scope := whatis_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("r", "rect", &r)
+	godebug.Line(ctx, scope, 24)
+	godebug.Returning(ctx)
+	return "rect"
+}
+
+func (r *rect) Scale(by float64) {
+	ctx, ok := godebug.EnterFunc("main.(*rect).Scale", "main/whatis-in.go", func() {
+		r.Scale(by)
+	})
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	scope := whatis_in_go_scope.EnteringNewChildScope()
+	scope.DeclareArgs("r", "*rect", &r, "by", "float64", &by)
+	godebug.Line(ctx, scope, 26)
+	r.W, r.H = r.W*by, r.H*by
+	godebug.Returning(ctx)
+}
+
+type celsius float64
+
+func main() {
+	ctx, ok := godebug.EnterFunc("main.main", "main/whatis-in.go", main)
+	if !ok {
+		return
+	}
+	defer godebug.ExitFunc(ctx)
+	godebug.Line(ctx, whatis_in_go_scope, 31)
+	const limit = 10
+	scope := whatis_in_go_scope.EnteringNewChildScope()
+	scope.Constant("limit", "untyped int", limit)
+	godebug.Line(ctx, scope, 32)
+	const freezing celsius = 0
+	scope.Constant("freezing", "celsius", freezing)
+	godebug.Line(ctx, scope, 33)
+	var err error
+	scope.Declare("err", "error", &err)
+	godebug.Line(ctx, scope, 34)
+	other := errors.New("boom")
+	scope.Declare("other", "error", &other)
+	godebug.Line(ctx, scope, 35)
+	var s shape = rect{W: 2, H: 3}
+	scope.Declare("s", "shape", &s)
+	godebug.Line(ctx, scope, 36)
+	b := byte('x')
+	scope.Declare("b", "byte", &b)
+	godebug.Line(ctx, scope, 37)
+	r := &rect{W: 1, H: 1}
+	scope.Declare("r", "*rect", &r)
+	godebug.Line(ctx, scope, 38)
+	var parse func(string, ...*template.Template) (n int, err error)
+	scope.Declare("parse", "func(string, ...*template.Template) (n int, err error)", &parse)
+	godebug.Line(ctx, scope, 39)
+	var results chan<- map[string][]template.Template
+	scope.Declare("results", "chan<- map[string][]template.Template", &results)
+	godebug.SetTraceGen(ctx)
+	godebug.Line(ctx, scope, 40)
+	godebug.Line(ctx, scope, 41)
+
+	fmt.Println(limit, freezing, err, other, s, b, r, parse == nil, results == nil)
+	godebug.Returning(ctx)
+}
+
+var whatis_in_go_contents = `package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+type shape interface {
+	Area() float64
+	fmt.Stringer
+}
+
+type rect struct {
+	W, H    float64
+	Created time.Time
+	io.Reader
+}
+
+func (r rect) Area() float64 { return r.W * r.H }
+
+func (r rect) String() string { return "rect" }
+
+func (r *rect) Scale(by float64) { r.W, r.H = r.W*by, r.H*by }
+
+type celsius float64
+
+func main() {
+	const limit = 10
+	const freezing celsius = 0
+	var err error
+	other := errors.New("boom")
+	var s shape = rect{W: 2, H: 3}
+	b := byte('x')
+	r := &rect{W: 1, H: 1}
+	var parse func(string, ...*template.Template) (n int, err error)
+	var results chan<- map[string][]template.Template
+	_ = "breakpoint"
+	fmt.Println(limit, freezing, err, other, s, b, r, parse == nil, results == nil)
+}
+`
@@ -0,0 +1,10 @@
+package main
+
+func crash(s []int) int {
+	return s[3]
+}
+
+func main() {
+	_ = "breakpoint"
+	crash(nil)
+}